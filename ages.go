@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ageBuckets split uncompleted todos by how long ago they were created, each one
+// holds the todos younger than its limit and not in an earlier bucket
+var ageBuckets = []struct {
+	label string
+	limit time.Duration
+}{
+	{"< 1 day", days(1)},
+	{"1-7 days", days(7)},
+	{"1-4 weeks", days(28)},
+	{"> 4 weeks", math.MaxInt64},
+}
+
+// agesCommand prints how many uncompleted todos are in each age bucket, as bars
+// of # on a terminal and plain numbers otherwise
+func agesCommand() int {
+	counts := make([]int, len(ageBuckets))
+	skipped := 0
+
+	for _, todo := range store.All(isPending) {
+		if todo.created.IsZero() {
+			skipped++
+			continue
+		}
+
+		age := time.Since(todo.created)
+		for i, bucket := range ageBuckets {
+			if age < bucket.limit {
+				counts[i]++
+				break
+			}
+		}
+	}
+
+	most := slices.Max(counts)
+
+	// room for the label and the count next to the bar
+	barWidth := max(terminal.width-20, 10)
+
+	for i, bucket := range ageBuckets {
+		if !terminal.tty || most == 0 {
+			fmt.Printf("%-10s %d\n", bucket.label, counts[i])
+			continue
+		}
+
+		bar := strings.Repeat("#", counts[i]*barWidth/most)
+		fmt.Printf("%-10s %s %d\n", bucket.label, bar, counts[i])
+	}
+
+	if skipped > 0 {
+		fmt.Printf("%d todos skipped, they don't know when they were created\n", skipped)
+	}
+
+	return 0
+}
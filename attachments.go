@@ -0,0 +1,108 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidAttachment is returned for attachments that aren't an existing file or
+// that have a line break, which would end their line in the todo file
+var ErrInvalidAttachment = errors.New("invalid attachment")
+
+func checkAttachment(path string) error {
+	if strings.ContainsAny(path, "\r\n") {
+		return fmt.Errorf("%w: %q has a line break", ErrInvalidAttachment, path)
+	}
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidAttachment, err)
+	}
+	return nil
+}
+
+func attachFile() {
+	id := getTodoId()
+
+	var updated *Todo
+	err := store.Update(id, func(todo *Todo) error {
+		fmt.Print("file: ")
+		filePath := strings.TrimSpace(readLine())
+
+		absPath, err := filepath.Abs(filePath)
+		if err != nil {
+			return err
+		}
+
+		if err := checkAttachment(absPath); err != nil {
+			return err
+		}
+
+		todo.attach(absPath)
+		updated = todo
+		return nil
+	})
+
+	if err != nil {
+		printTodoError(id, err)
+		return
+	}
+
+	emitEvent("edit", updated)
+
+	fmt.Printf("%d files attached\n", len(updated.attachments))
+}
+
+func openAttachment() {
+	id := getTodoId()
+	todo, err := LoadTodo(id)
+
+	if err != nil {
+		printTodoError(id, err)
+		return
+	}
+
+	if len(todo.attachments) == 0 {
+		fmt.Println("Todo has no attachments")
+		return
+	}
+
+	printAttachments(todo)
+
+	fmt.Print("Select attachment: ")
+	n, err := strconv.Atoi(strings.TrimSpace(readLine()))
+	if err != nil || n < 1 || n > len(todo.attachments) {
+		fmt.Println("Attachment not found")
+		return
+	}
+
+	if err := openFile(todo.attachments[n-1]); err != nil {
+		fmt.Printf("Error opening %s: %+v\n", todo.attachments[n-1], err)
+	}
+}
+
+func printAttachments(todo *Todo) {
+	for i, attachment := range todo.attachments {
+		fmt.Printf("%d) %s\n", i+1, attachment)
+	}
+}
+
+// openFile hands the file to whatever the OS opens this kind of file with
+func openFile(file string) error {
+	var cmd *exec.Cmd
+
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", file)
+	case "windows":
+		cmd = exec.Command("cmd", "/c", "start", "", file)
+	default:
+		cmd = exec.Command("xdg-open", file)
+	}
+
+	return cmd.Start()
+}
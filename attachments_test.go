@@ -0,0 +1,55 @@
+package main
+
+import (
+	"os"
+	"path"
+	"slices"
+	"testing"
+)
+
+func TestAttachmentsKeptForOlderFormats(t *testing.T) {
+	for format, data := range map[string]string{
+		formatText:   "completed: false\nbuy milk\n",
+		formatBinary: "\x00buy milk",
+	} {
+		dir := useTestDir(t)
+		if err := os.WriteFile(path.Join(dir, "5"), []byte(data), 0666); err != nil {
+			t.Fatal(err)
+		}
+
+		err := store.Update(5, func(todo *Todo) error {
+			todo.attach("/home/me/shopping.txt")
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		loaded, err := LoadTodo(5)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(loaded.attachments, []string{"/home/me/shopping.txt"}) || loaded.title != "buy milk" {
+			t.Errorf("%s todo after attaching: %+v, want the attachment kept", format, loaded)
+		}
+	}
+}
+
+func TestAttachmentsKeptWithTextFormat(t *testing.T) {
+	useTestDir(t)
+	config.format = formatText
+
+	todo := &Todo{id: 5, title: "buy milk"}
+	todo.attach("/home/me/shopping.txt")
+	if err := todo.save(); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadTodo(5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(loaded.attachments, []string{"/home/me/shopping.txt"}) || loaded.title != "buy milk" {
+		t.Errorf("todo saved with format = text: %+v, want the attachment kept", loaded)
+	}
+}
@@ -0,0 +1,382 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// runCommand runs a one-shot subcommand given on the command line and
+// returns the exit code
+func runCommand(args []string) int {
+	if changingCommands[args[0]] {
+		if refuseInReadOnly(args[0]) {
+			return 1
+		}
+		requireWritable()
+	}
+
+	switch args[0] {
+	case "add":
+		return addCommand(args[1:])
+	case "complete-all":
+		return completeAllCommand()
+	case "migrate":
+		return migrateCommand()
+	case "normalize":
+		return normalizeCommand()
+	case "check":
+		return checkCommand()
+	case "prompt":
+		return promptCommand()
+	case "ages":
+		return agesCommand()
+	case "stale":
+		return staleCommand(args[1:])
+	case "serve":
+		return serveCommand(args[1:])
+	case "snapshot":
+		return snapshotCommand(args[1:])
+	case "apply-snapshot":
+		return applySnapshotCommand(args[1:])
+	case "diff-snapshot":
+		return diffSnapshotCommand(args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown command: %s\n", args[0])
+		return 2
+	}
+}
+
+// askYesNo reads the answer from stdin, anything but y or yes (no input too) means no
+func askYesNo(question string) bool {
+	failNonInteractive(fmt.Sprintf("%q needs confirmation", question))
+
+	fmt.Printf("%s [y/N] ", question)
+
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+
+	return answer == "y" || answer == "yes"
+}
+
+// addCommand adds a todo titled with the arguments joined by spaces, so
+// `todo add "buy milk"` and `todo add buy milk` are the same. Everything
+// after -- is title, even if it looks like a flag: `todo add -- --done`.
+// A literal \n only becomes a new line with --multiline
+func addCommand(args []string) int {
+	flags := flag.NewFlagSet("add", flag.ContinueOnError)
+	multiline := flags.Bool("multiline", false, `store \n in the title as a new line, without it \n is kept as typed`)
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	title := strings.Join(flags.Args(), " ")
+	if *multiline {
+		title = strings.ReplaceAll(title, `\n`, "\n")
+	}
+
+	if err := validateTitle(title); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid title: %v\n", err)
+		return 1
+	}
+
+	if !checkTodoLimit() {
+		return 1
+	}
+
+	todo, err := addTodo(title)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error saving todo: %+v\n", err)
+		return 1
+	}
+	printSaved(todo)
+
+	return 0
+}
+
+// completeAllCommand completes every id read from stdin, ids are separated
+// by spaces or newlines, e.g. `echo 5 12 | todo complete-all`
+func completeAllCommand() int {
+	completed, failed := 0, 0
+
+	scanner := bufio.NewScanner(os.Stdin)
+	scanner.Split(bufio.ScanWords)
+
+	for scanner.Scan() {
+		idRaw := scanner.Text()
+
+		idInt, err := strconv.ParseUint(idRaw, 10, 0)
+		if err != nil {
+			fmt.Printf("%s: invalid id\n", idRaw)
+			failed++
+			continue
+		}
+
+		id := TODOId(idInt)
+
+		var updated *Todo
+		err = store.Update(id, func(todo *Todo) error {
+			todo.complete()
+			updated = todo
+			return nil
+		})
+		if err != nil {
+			fmt.Printf("%d: %v\n", id, err)
+			failed++
+			continue
+		}
+		emitEvent("complete", updated)
+
+		fmt.Printf("%d: completed\n", id)
+		completed++
+	}
+
+	if err := scanner.Err(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading ids: %+v\n", err)
+		return 1
+	}
+
+	fmt.Printf("%d completed, %d failed\n", completed, failed)
+
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// migrateCommand rewrites todos saved in another format than the configured one,
+// like the old binary format, in the configured format
+func migrateCommand() int {
+	migrated, failed := 0, 0
+
+	// one scan for all todos, looking each one up would read the directory again
+	index := indexTodoFiles()
+
+	for _, id := range index.ids() {
+		filepath, err := index.path(id)
+		if err != nil {
+			fmt.Printf("%d: %v\n", id, err)
+			failed++
+			continue
+		}
+
+		data, err := os.ReadFile(filepath)
+		if err != nil {
+			fmt.Printf("%d: %v\n", id, err)
+			failed++
+			continue
+		}
+
+		format := detectFormat(data)
+		if format == config.format {
+			continue
+		}
+
+		todo := parseTodo(id, data)
+
+		if err := writeTodo(filepath, todo); err != nil {
+			fmt.Printf("%d: %v\n", todo.id, err)
+			failed++
+			continue
+		}
+
+		fmt.Printf("%d: migrated from %s\n", todo.id, format)
+		migrated++
+	}
+
+	fmt.Printf("%d migrated, %d failed\n", migrated, failed)
+
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// checkCommand reports problems with the stored todos and exits with 1 if there are any
+func checkCommand() int {
+	problems := 0
+
+	for id, group := range duplicateTodoFiles(scanTodoFiles()) {
+		paths := make([]string, 0, len(group))
+		for _, file := range group {
+			paths = append(paths, file.path)
+		}
+		fmt.Printf("%d: stored in several files: %s\n", id, strings.Join(paths, ", "))
+		problems++
+	}
+
+	for _, dir := range todoDirs() {
+		fmt.Printf("%s: %v\n", dir, ErrTodoIsDir)
+		problems++
+	}
+
+	for _, todo := range store.All(nil) {
+		for _, attachment := range todo.attachments {
+			if _, err := os.Stat(attachment); err != nil {
+				fmt.Printf("%d: attachment %s: %v\n", todo.id, attachment, err)
+				problems++
+			}
+		}
+	}
+
+	fmt.Printf("%d problems found\n", problems)
+
+	if problems > 0 {
+		return 1
+	}
+	return 0
+}
+
+// normalizeTitle trims the title and drops control characters, new lines stay
+// since titles added with --multiline can have them
+func normalizeTitle(title string) string {
+	title = strings.Map(func(r rune) rune {
+		if r != '\n' && unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, title)
+
+	return strings.TrimSpace(title)
+}
+
+// writeBackup saves a snapshot of every todo under backups/ in the todos directory
+func writeBackup(name string) (string, error) {
+	dir := path.Join(getDirPath(), "backups")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+
+	data, err := marshalSnapshot(takeSnapshot())
+	if err != nil {
+		return "", err
+	}
+
+	filepath := path.Join(dir, fmt.Sprintf("%s-%s.json", name, time.Now().Format("20060102-150405")))
+
+	return filepath, os.WriteFile(filepath, data, 0666)
+}
+
+// normalizeCommand rewrites every todo in the configured format under its canonical
+// file name with a clean title. Of an id in several files only the one listings
+// show is kept, the others are moved next to the backup. Running it again changes nothing
+func normalizeCommand() int {
+	backup, err := writeBackup("normalize")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing backup, nothing changed: %+v\n", err)
+		return 1
+	}
+	fmt.Printf("Backup saved to %s\n", backup)
+
+	// the snapshot only has the todos listings show, so the other files are kept as they are
+	duplicatesDir := strings.TrimSuffix(backup, ".json") + "-duplicates"
+
+	counts := map[string]int{}
+
+	index := indexTodoFiles()
+
+	for _, id := range index.ids() {
+		group := index[id]
+		file := group[0]
+
+		if len(group) > 1 {
+			picked, ok := pickTodoFile(group)
+			if !ok {
+				fmt.Printf("todo %d: failed: it's in %d files and duplicates = skip, run check for details\n", id, len(group))
+				counts["failed"]++
+				continue
+			}
+			// before writing, the canonical file may be one of the others
+			if !moveDuplicates(group, picked, duplicatesDir, counts) {
+				continue
+			}
+			file = picked
+		}
+
+		normalizeTodoFile(file, counts)
+	}
+
+	fmt.Printf("%d migrated, %d cleaned, %d unchanged, %d duplicates removed, %d failed\n",
+		counts["migrated"], counts["cleaned"], counts["unchanged"], counts["removed"], counts["failed"])
+
+	if counts["failed"] > 0 {
+		return 1
+	}
+	return 0
+}
+
+// moveDuplicates moves every file of the group but picked into dir, false if one couldn't be moved
+func moveDuplicates(group []todoFile, picked todoFile, dir string, counts map[string]int) bool {
+	for _, file := range group {
+		if file == picked {
+			continue
+		}
+
+		moved := path.Join(dir, path.Base(file.path))
+		err := os.MkdirAll(dir, 0755)
+		if err == nil {
+			err = os.Rename(file.path, moved)
+		}
+		if err != nil {
+			fmt.Printf("%s: failed to move duplicate of %s: %v\n", file.path, picked.path, err)
+			counts["failed"]++
+			return false
+		}
+
+		fmt.Printf("%s: removed, duplicate of %s, moved to %s\n", file.path, picked.path, moved)
+		counts["removed"]++
+	}
+
+	return true
+}
+
+// normalizeTodoFile rewrites one file if it isn't already normalized and counts what was done
+func normalizeTodoFile(file todoFile, counts map[string]int) {
+	data, err := os.ReadFile(file.path)
+	if err != nil {
+		fmt.Printf("%s: failed: %v\n", file.path, err)
+		counts["failed"]++
+		return
+	}
+
+	todo := parseTodo(file.id, data)
+	actions := make([]string, 0, 2)
+
+	canonicalPath := getFilePath(file.id)
+	if detectFormat(data) != config.format || file.path != canonicalPath || todo.uuid == "" || todo.rank == 0 {
+		actions = append(actions, "migrated")
+	}
+	if title := normalizeTitle(todo.title); title != todo.title {
+		todo.update(title)
+		actions = append(actions, "cleaned")
+	}
+
+	if len(actions) == 0 {
+		counts["unchanged"]++
+		return
+	}
+
+	if err := writeTodo(canonicalPath, todo); err != nil {
+		fmt.Printf("%s: failed: %v\n", file.path, err)
+		counts["failed"]++
+		return
+	}
+	if file.path != canonicalPath {
+		if err := os.Remove(file.path); err != nil {
+			fmt.Printf("%s: failed to remove after writing %s: %v\n", file.path, canonicalPath, err)
+			counts["failed"]++
+			return
+		}
+	}
+
+	fmt.Printf("%s: %s\n", file.path, strings.Join(actions, ", "))
+	for _, action := range actions {
+		counts[action]++
+	}
+}
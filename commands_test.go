@@ -0,0 +1,118 @@
+package main
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestAddCommandNewlines(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{`buy milk\nand eggs`}, `buy milk\nand eggs`},
+		{[]string{"buy", `milk\n`}, `buy milk\n`},
+		{[]string{"--multiline", `buy milk\nand eggs`}, "buy milk\nand eggs"},
+		{[]string{"--multiline", "buy", `milk\n\nand`, "eggs"}, "buy milk\n\nand eggs"},
+		// without an escape --multiline changes nothing
+		{[]string{"--multiline", "buy milk"}, "buy milk"},
+	}
+
+	for _, test := range tests {
+		useTestDir(t)
+
+		if code := addCommand(test.args); code != 0 {
+			t.Fatalf("addCommand(%q) exited with %d", test.args, code)
+		}
+
+		todos := store.All(nil)
+		if len(todos) != 1 || todos[0].title != test.want {
+			t.Errorf("addCommand(%q) saved %v, want one todo titled %q", test.args, todos, test.want)
+		}
+	}
+}
+
+// todoFileNames lists the todo files left in the todos directory
+func todoFileNames(t *testing.T) []string {
+	t.Helper()
+
+	names := []string{}
+	for _, file := range scanTodoFiles() {
+		names = append(names, path.Base(file.path))
+	}
+	slices.Sort(names)
+	return names
+}
+
+func TestNormalizeDuplicates(t *testing.T) {
+	dir := useTestDir(t)
+
+	now := time.Now()
+	writeTodoFile(t, "5", "\x00old five", now.Add(-time.Hour))
+	writeTodoFile(t, "05", "\x01new five in 05", now)
+	writeTodoFile(t, "007", "\x00  seven ", now)
+	writeTodoFile(t, "8", "completed: false\neight", now)
+
+	if code := normalizeCommand(); code != 0 {
+		t.Fatalf("normalize exited with %d", code)
+	}
+
+	if names := todoFileNames(t); !slices.Equal(names, []string{"5", "7", "8"}) {
+		t.Errorf("files after normalize = %v, want only canonical names", names)
+	}
+	if len(duplicateTodoFiles(scanTodoFiles())) > 0 {
+		t.Error("duplicates left after normalize")
+	}
+
+	todo, err := LoadTodo(5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if todo.title != "new five in 05" || !todo.completed || todo.uuid == "" || todo.rank == 0 {
+		t.Errorf("todo 5 = %+v, want the newest file migrated", todo)
+	}
+	if todo, _ := LoadTodo(7); todo == nil || todo.title != "seven" {
+		t.Errorf("todo 7 = %+v, want the cleaned title", todo)
+	}
+
+	// the older duplicate isn't in the snapshot, so it's kept in the backups
+	moved, _ := filepath.Glob(path.Join(dir, "backups", "normalize-*-duplicates", "5"))
+	if len(moved) != 1 {
+		t.Errorf("older duplicate moved to %v, want one file in the backups", moved)
+	}
+
+	before := todoFileNames(t)
+	data, _ := os.ReadFile(getFilePath(5))
+	if code := normalizeCommand(); code != 0 {
+		t.Fatalf("normalize exited with %d on the second run", code)
+	}
+	after, _ := os.ReadFile(getFilePath(5))
+	if !slices.Equal(before, todoFileNames(t)) || string(data) != string(after) {
+		t.Error("a second normalize changed files")
+	}
+}
+
+func TestNormalizeSkipsDuplicatesWithSkip(t *testing.T) {
+	useTestDir(t)
+	config.duplicates = duplicatesSkip
+
+	now := time.Now()
+	writeTodoFile(t, "5", "\x00old five", now.Add(-time.Hour))
+	writeTodoFile(t, "05", "\x00new five in 05", now)
+	writeTodoFile(t, "6", "\x00six", now)
+
+	if code := normalizeCommand(); code != 1 {
+		t.Errorf("normalize exited with %d, want 1 for the skipped todo", code)
+	}
+
+	if names := todoFileNames(t); !slices.Equal(names, []string{"05", "5", "6"}) {
+		t.Errorf("files after normalize = %v, want the duplicates left alone", names)
+	}
+	if todo, _ := LoadTodo(6); todo == nil || todo.uuid == "" {
+		t.Errorf("todo 6 = %+v, want it migrated anyway", todo)
+	}
+}
@@ -0,0 +1,303 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const appName = "go-todo-cli"
+
+const (
+	duplicatesNewest = "newest"
+	duplicatesSkip   = "skip"
+)
+
+var dirFlag = flag.String("dir", "", "directory to store todos in (overrides TODO_DIR)")
+
+type Config struct {
+	dir     string
+	format  string
+	timeout time.Duration
+
+	maxTodos                 int
+	maxTodosRefuse           bool
+	maxTodosExcludeCompleted bool
+
+	titleMaxLength      int
+	titleForbiddenChars string
+	titlePattern        *regexp.Regexp
+
+	rankIds      bool
+	reopenOnEdit bool
+	duplicates   string
+
+	strikethrough bool
+	theme         string
+
+	helpOrder     string
+	rememberUsage bool
+
+	promptFormat string
+
+	stalePendingDays   int
+	staleCompletedDays int
+
+	readOnly bool
+}
+
+var config Config
+
+var dataDir string
+
+// xdgDir follows the XDG base directory spec: the env var wins when it holds
+// an absolute path, otherwise fall back to the given dir under home
+func xdgDir(env, fallback string) string {
+	if dir := os.Getenv(env); path.IsAbs(dir) {
+		return path.Join(dir, appName)
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return path.Join(home, fallback, appName)
+}
+
+func getConfigDir() string {
+	if runtime.GOOS == "linux" {
+		return xdgDir("XDG_CONFIG_HOME", ".config")
+	}
+
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return path.Join(dir, appName)
+}
+
+func resolveDataDir() string {
+	if *dirFlag != "" {
+		return *dirFlag
+	}
+
+	if dir := os.Getenv("TODO_DIR"); dir != "" {
+		return dir
+	}
+
+	if config.dir != "" {
+		return config.dir
+	}
+
+	if runtime.GOOS == "linux" {
+		return xdgDir("XDG_DATA_HOME", ".local/share")
+	}
+
+	dir, err := os.Getwd()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return path.Join(dir, "todos")
+}
+
+func loadConfig(dir string) Config {
+	config := Config{
+		format:     formatHeader,
+		duplicates: duplicatesNewest,
+		theme:      "default",
+		helpOrder:  helpOrderFixed,
+
+		stalePendingDays:   30,
+		staleCompletedDays: 7,
+	}
+
+	file, err := os.Open(path.Join(dir, "config"))
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			fmt.Fprintf(os.Stderr, "Error reading config: %+v\n", err)
+		}
+		return config
+	}
+	defer file.Close()
+
+	// one "key = value" per line, lines starting with # are comments
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, value, found := strings.Cut(line, "=")
+		if !found {
+			fmt.Fprintf(os.Stderr, "Invalid config line: %s\n", line)
+			continue
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "dir":
+			config.dir = value
+		case "format":
+			switch value {
+			case formatHeader, formatText:
+				config.format = value
+			case formatBinary:
+				// it has no room for the uuid, binary files are still read
+				fmt.Fprintf(os.Stderr, "format = binary is no longer supported, using %s\n", config.format)
+			default:
+				fmt.Fprintf(os.Stderr, "Unknown format %q, using %s\n", value, config.format)
+			}
+		case "timeout":
+			minutes, err := strconv.Atoi(value)
+			if err != nil || minutes < 0 {
+				fmt.Fprintf(os.Stderr, "Invalid timeout %q, expected minutes\n", value)
+				continue
+			}
+			config.timeout = time.Duration(minutes) * time.Minute
+		case "max_todos":
+			max, err := strconv.Atoi(value)
+			if err != nil || max < 0 {
+				fmt.Fprintf(os.Stderr, "Invalid max_todos %q, expected a number\n", value)
+				continue
+			}
+			config.maxTodos = max
+		case "max_todos_refuse":
+			setBool(&config.maxTodosRefuse, key, value)
+		case "max_todos_exclude_completed":
+			setBool(&config.maxTodosExcludeCompleted, key, value)
+		case "title_max_length":
+			max, err := strconv.Atoi(value)
+			if err != nil || max < 0 {
+				fmt.Fprintf(os.Stderr, "Invalid title_max_length %q, expected a number\n", value)
+				continue
+			}
+			config.titleMaxLength = max
+		case "title_forbidden_chars":
+			config.titleForbiddenChars = value
+		case "title_pattern":
+			pattern, err := regexp.Compile(value)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Invalid title_pattern %q: %+v\n", value, err)
+				continue
+			}
+			config.titlePattern = pattern
+		case "rank_ids":
+			setBool(&config.rankIds, key, value)
+		case "reopen_on_edit":
+			setBool(&config.reopenOnEdit, key, value)
+		case "duplicates":
+			if value != duplicatesNewest && value != duplicatesSkip {
+				fmt.Fprintf(os.Stderr, "Unknown duplicates %q, using %s\n", value, config.duplicates)
+				continue
+			}
+			config.duplicates = value
+		case "strikethrough":
+			setBool(&config.strikethrough, key, value)
+		case "theme":
+			if _, found := themes[value]; !found {
+				fmt.Fprintf(os.Stderr, "Unknown theme %q, using %s\n", value, config.theme)
+				continue
+			}
+			config.theme = value
+		case "help_order":
+			if value != helpOrderFixed && value != helpOrderUsage {
+				fmt.Fprintf(os.Stderr, "Unknown help_order %q, using %s\n", value, config.helpOrder)
+				continue
+			}
+			config.helpOrder = value
+		case "remember_usage":
+			setBool(&config.rememberUsage, key, value)
+		case "prompt_format":
+			config.promptFormat = value
+		case "read_only":
+			setBool(&config.readOnly, key, value)
+		case "stale_pending_days", "stale_completed_days":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				fmt.Fprintf(os.Stderr, "Invalid %s %q, expected a number of days\n", key, value)
+				continue
+			}
+			if key == "stale_pending_days" {
+				config.stalePendingDays = n
+			} else {
+				config.staleCompletedDays = n
+			}
+		default:
+			fmt.Fprintf(os.Stderr, "Unknown config key: %s\n", key)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading config: %+v\n", err)
+	}
+
+	return config
+}
+
+func setBool(target *bool, key, value string) {
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid %s %q, expected true or false\n", key, value)
+		return
+	}
+	*target = b
+}
+
+func initDirs() {
+	configDir := getConfigDir()
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		log.Fatal(err)
+	}
+	config = loadConfig(configDir)
+
+	dataDir = resolveDataDir()
+}
+
+// requireWritable stops the app when todos can't be saved in the todos directory.
+// Only the menu and commands that change todos need it, so quick read-only commands
+// like prompt don't create and remove a file on every run
+func requireWritable() {
+	if err := checkWritable(dataDir); err != nil {
+		fmt.Fprintf(os.Stderr, "Can't write to todos directory %s: %+v\n", dataDir, err)
+		os.Exit(1)
+	}
+}
+
+// requireReadable is requireWritable for read-only mode, where someone else's
+// directory is fine too
+func requireReadable() {
+	if _, err := os.ReadDir(dataDir); err != nil {
+		fmt.Fprintf(os.Stderr, "Can't read todos directory %s: %+v\n", dataDir, err)
+		os.Exit(1)
+	}
+}
+
+// checkWritable creates the dir if needed and makes sure files can be created there,
+// so a bad path shows up now rather than as a log.Fatal inside save()
+func checkWritable(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	file, err := os.CreateTemp(dir, ".write-check-")
+	if err != nil {
+		return err
+	}
+	file.Close()
+
+	return os.Remove(file.Name())
+}
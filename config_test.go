@@ -0,0 +1,85 @@
+package main
+
+import (
+	"os"
+	"path"
+	"strings"
+	"testing"
+)
+
+// captureOutput runs fn with stdout and stderr going to files and returns what was written
+func captureOutput(t *testing.T, fn func()) (string, string) {
+	t.Helper()
+
+	read := func(file *os.File) string {
+		data, err := os.ReadFile(file.Name())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+
+	stdout, err := os.CreateTemp(t.TempDir(), "stdout")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stdout.Close()
+	stderr, err := os.CreateTemp(t.TempDir(), "stderr")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stderr.Close()
+
+	savedStdout, savedStderr := os.Stdout, os.Stderr
+	os.Stdout, os.Stderr = stdout, stderr
+	defer func() { os.Stdout, os.Stderr = savedStdout, savedStderr }()
+
+	fn()
+
+	return read(stdout), read(stderr)
+}
+
+func TestConfigWarningsGoToStderr(t *testing.T) {
+	dir := t.TempDir()
+	lines := []string{
+		"no equals sign",
+		"format = binary",
+		"timeout = soon",
+		"max_todos = many",
+		"title_max_length = long",
+		"title_pattern = [",
+		"duplicates = oldest",
+		"theme = plaid",
+		"help_order = random",
+		"stale_pending_days = old",
+		"strikethrough = maybe",
+		"colour = red",
+	}
+	if err := os.WriteFile(path.Join(dir, "config"), []byte(strings.Join(lines, "\n")), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	stdout, stderr := captureOutput(t, func() { loadConfig(dir) })
+
+	// `$(todo-app prompt)` in PS1 captures stdout, a warning there ends up in the prompt
+	if stdout != "" {
+		t.Errorf("loadConfig wrote to stdout: %q", stdout)
+	}
+	if got := strings.Count(stderr, "\n"); got != len(lines) {
+		t.Errorf("loadConfig wrote %d warnings to stderr, want %d:\n%s", got, len(lines), stderr)
+	}
+}
+
+func TestConfigReadErrorGoesToStderr(t *testing.T) {
+	dir := t.TempDir()
+	// a directory where the config file should be can't be read
+	if err := os.Mkdir(path.Join(dir, "config"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	stdout, stderr := captureOutput(t, func() { loadConfig(dir) })
+
+	if stdout != "" || !strings.Contains(stderr, "Error reading config") {
+		t.Errorf("loadConfig wrote %q to stdout and %q to stderr, want the error on stderr", stdout, stderr)
+	}
+}
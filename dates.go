@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// parseDate reads a day as 2024-05-01, today, yesterday or -7d for a week ago,
+// in the local time zone. The result is the start of that day
+func parseDate(s string) (time.Time, error) {
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+
+	s = strings.ToLower(strings.TrimSpace(s))
+
+	switch s {
+	case "today":
+		return today, nil
+	case "yesterday":
+		return today.AddDate(0, 0, -1), nil
+	}
+
+	if daysRaw, found := strings.CutPrefix(s, "-"); found && strings.HasSuffix(daysRaw, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(daysRaw, "d"))
+		if err == nil && days >= 0 {
+			return today.AddDate(0, 0, -days), nil
+		}
+	}
+
+	day, err := time.ParseInLocation(time.DateOnly, s, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q, expected YYYY-MM-DD, today, yesterday or -Nd", s)
+	}
+
+	return day, nil
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDate(t *testing.T) {
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"2024-05-01", time.Date(2024, 5, 1, 0, 0, 0, 0, time.Local)},
+		{" today ", today},
+		{"Yesterday", today.AddDate(0, 0, -1)},
+		{"-7d", today.AddDate(0, 0, -7)},
+		{"-0d", today},
+	}
+	for _, test := range tests {
+		got, err := parseDate(test.in)
+		if err != nil || !got.Equal(test.want) {
+			t.Errorf("parseDate(%q) = %v, %v, want %v", test.in, got, err, test.want)
+		}
+	}
+
+	for _, in := range []string{"", "tomorrow", "2024-13-01", "-d", "7d", "-xd"} {
+		if _, err := parseDate(in); err == nil {
+			t.Errorf("parseDate(%q) didn't fail", in)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import "time"
+
+// TodoDTO is the JSON form of a Todo. Todo keeps its fields unexported, so
+// everything that serializes todos (snapshots for now) goes through this type
+// and the field names here are the stable wire format
+type TodoDTO struct {
+	Id          TODOId     `json:"id"`
+	UUID        string     `json:"uuid,omitempty"`
+	Rank        int        `json:"rank,omitempty"`
+	Created     *time.Time `json:"created,omitempty"`
+	Completed   bool       `json:"completed"`
+	Title       string     `json:"title"`
+	Attachments []string   `json:"attachments,omitempty"`
+}
+
+func toDTO(todo *Todo) TodoDTO {
+	dto := TodoDTO{
+		Id:          todo.id,
+		UUID:        todo.uuid,
+		Rank:        todo.rank,
+		Completed:   todo.completed,
+		Title:       todo.title,
+		Attachments: todo.attachments,
+	}
+	if !todo.created.IsZero() {
+		created := todo.created
+		dto.Created = &created
+	}
+
+	return dto
+}
+
+func fromDTO(dto TodoDTO) *Todo {
+	todo := &Todo{
+		id:          dto.Id,
+		uuid:        dto.UUID,
+		rank:        dto.Rank,
+		completed:   dto.Completed,
+		title:       dto.Title,
+		attachments: dto.Attachments,
+	}
+	if dto.Created != nil {
+		todo.created = *dto.Created
+	}
+
+	return todo
+}
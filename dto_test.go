@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+	"time"
+)
+
+// sameTodo compares every field a snapshot carries
+func sameTodo(a, b *Todo) bool {
+	return a.id == b.id && a.uuid == b.uuid && a.rank == b.rank && a.created.Equal(b.created) &&
+		a.completed == b.completed && a.title == b.title && slices.Equal(a.attachments, b.attachments)
+}
+
+func TestDTORoundTrip(t *testing.T) {
+	todos := []*Todo{
+		{
+			id:          5,
+			uuid:        "8f14e45f-ceea-4e6b-9b1a-3c2d5a6e7f80",
+			rank:        3,
+			created:     time.Date(2024, 5, 1, 9, 30, 0, 0, time.FixedZone("", 2*60*60)),
+			completed:   true,
+			title:       "buy milk\nand eggs",
+			attachments: []string{"/home/me/shopping.txt", "/home/me/recipe.pdf"},
+		},
+		// a todo from an older format, without uuid, rank or created
+		{id: 12, title: "walk dog"},
+		{id: 0, title: ""},
+	}
+
+	for _, todo := range todos {
+		data, err := json.Marshal(toDTO(todo))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var dto TodoDTO
+		if err := json.Unmarshal(data, &dto); err != nil {
+			t.Fatal(err)
+		}
+
+		if parsed := fromDTO(dto); !sameTodo(parsed, todo) {
+			t.Errorf("round trip through %s = %+v, want %+v", data, parsed, todo)
+		}
+	}
+}
+
+func TestDTOMissingFieldsOmitted(t *testing.T) {
+	data, err := json.Marshal(toDTO(&Todo{id: 12, title: "walk dog"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a zero created would come back as year 1 instead of "not known"
+	want := `{"id":12,"completed":false,"title":"walk dog"}`
+	if string(data) != want {
+		t.Errorf("marshaled %s, want %s", data, want)
+	}
+}
+
+func TestDTOAttachmentsNilAndEmpty(t *testing.T) {
+	nilData, err := json.Marshal(toDTO(&Todo{id: 5, title: "buy milk"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	emptyData, err := json.Marshal(toDTO(&Todo{id: 5, title: "buy milk", attachments: []string{}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(nilData) != string(emptyData) {
+		t.Errorf("nil attachments marshal to %s, empty ones to %s, want the same", nilData, emptyData)
+	}
+
+	// snapshots written by hand may have "attachments": [] or null
+	for _, data := range []string{
+		string(nilData),
+		`{"id":5,"completed":false,"title":"buy milk","attachments":[]}`,
+		`{"id":5,"completed":false,"title":"buy milk","attachments":null}`,
+	} {
+		var dto TodoDTO
+		if err := json.Unmarshal([]byte(data), &dto); err != nil {
+			t.Fatal(err)
+		}
+
+		todo := fromDTO(dto)
+		if len(todo.attachments) != 0 {
+			t.Errorf("fromDTO(%s) has attachments %q, want none", data, todo.attachments)
+		}
+		if string(mustEncode(t, todo, formatHeader)) != string(mustEncode(t, &Todo{id: 5, title: "buy milk"}, formatHeader)) {
+			t.Errorf("fromDTO(%s) is saved differently than a todo without attachments", data)
+		}
+	}
+}
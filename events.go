@@ -0,0 +1,24 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+var eventsFlag = flag.Bool("events", false, "print a line to stderr for every change, e.g. \"event=complete id=5\"")
+
+// emitEvent reports a state change in a stable key=value form for scripts and tests,
+// it goes to stderr so it never mixes with the normal output
+func emitEvent(event string, todo *Todo) {
+	if !*eventsFlag {
+		return
+	}
+
+	if event == "delete" {
+		fmt.Fprintf(os.Stderr, "event=%s id=%d\n", event, todo.id)
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, "event=%s id=%d completed=%t title=%q\n", event, todo.id, todo.completed, todo.title)
+}
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	formatHeader = "header"
+	formatBinary = "binary"
+	formatText   = "text"
+)
+
+// ErrLineBreakInValue is returned when saving a todo whose uuid or attachment has a
+// line break, it would end the line and start another key, or the title
+var ErrLineBreakInValue = errors.New("uuid or attachment contains a line break")
+
+// files in the header format start with this byte, it can't be confused with
+// the 0x0/0x1 completed byte of the binary format or the text format's "c"
+const headerFormatVersion = 0x2
+
+// encodeTodo serializes the todo in one of the file formats. The header format
+// is a version byte, "key=value" lines, an empty line and then the title:
+//
+//	\x02uuid=8f14e45f-ceea-4e6b-9b1a-3c2d5a6e7f80
+//	created=2024-05-01T09:30:00+02:00
+//	completed=true
+//	attachment=/home/me/shopping.txt
+//
+//	buy milk
+//
+// The text format is for editing by hand, the same keys as "key: value" lines
+// after the completed one, then the title:
+//
+//	completed: true
+//	uuid: 8f14e45f-ceea-4e6b-9b1a-3c2d5a6e7f80
+//	attachment: /home/me/shopping.txt
+//	buy milk
+//
+// The binary format has no room for the uuid, so it's only read
+func encodeTodo(todo *Todo, format string) ([]byte, error) {
+	// titles can have several lines, they come last
+	for _, value := range append([]string{todo.uuid}, todo.attachments...) {
+		if strings.ContainsAny(value, "\r\n") {
+			return nil, fmt.Errorf("%w: %q", ErrLineBreakInValue, value)
+		}
+	}
+
+	if format == formatText {
+		return encodeText(todo), nil
+	}
+
+	data := []byte{headerFormatVersion}
+	if todo.uuid != "" {
+		data = fmt.Appendf(data, "uuid=%s\n", todo.uuid)
+	}
+	if todo.rank > 0 {
+		data = fmt.Appendf(data, "rank=%d\n", todo.rank)
+	}
+	// todos from before this was written don't know when they were created
+	if !todo.created.IsZero() {
+		data = fmt.Appendf(data, "created=%s\n", todo.created.Format(time.RFC3339))
+	}
+	data = fmt.Appendf(data, "completed=%t\n", todo.completed)
+	for _, attachment := range todo.attachments {
+		data = fmt.Appendf(data, "attachment=%s\n", attachment)
+	}
+	data = append(data, '\n')
+
+	return append(data, todo.title...), nil
+}
+
+func encodeText(todo *Todo) []byte {
+	data := fmt.Appendf(nil, "completed: %t\n", todo.completed)
+	if todo.uuid != "" {
+		data = fmt.Appendf(data, "uuid: %s\n", todo.uuid)
+	}
+	if todo.rank > 0 {
+		data = fmt.Appendf(data, "rank: %d\n", todo.rank)
+	}
+	if !todo.created.IsZero() {
+		data = fmt.Appendf(data, "created: %s\n", todo.created.Format(time.RFC3339))
+	}
+	for _, attachment := range todo.attachments {
+		data = fmt.Appendf(data, "attachment: %s\n", attachment)
+	}
+
+	return fmt.Appendf(data, "%s\n", todo.title)
+}
+
+func detectFormat(data []byte) string {
+	switch {
+	case len(data) > 0 && data[0] == headerFormatVersion:
+		return formatHeader
+	case strings.HasPrefix(string(data), "completed: "):
+		return formatText
+	default:
+		return formatBinary
+	}
+}
+
+func parseTodo(id TODOId, data []byte) *Todo {
+	todo := &Todo{id: id}
+
+	switch detectFormat(data) {
+	case formatHeader:
+		parseHeader(todo, string(data[1:]))
+	case formatText:
+		parseText(todo, strings.TrimPrefix(string(data), "completed: "))
+	default:
+		if len(data) > 0 {
+			// I couldn't find a way to read only first bit, so reading the first byte and checking it's value
+			todo.completed = data[0]&0x1 == 0x1
+			todo.title = string(data[1:])
+		}
+	}
+
+	return todo
+}
+
+// parseHeader reads the header lines up to the empty one, keys it doesn't
+// know are skipped so files from newer versions still load
+func parseHeader(todo *Todo, rest string) {
+	for rest != "" {
+		line, after, _ := strings.Cut(rest, "\n")
+		rest = after
+		if line == "" {
+			break
+		}
+
+		key, value, _ := strings.Cut(line, "=")
+		switch key {
+		case "uuid":
+			todo.uuid = value
+		case "rank":
+			todo.rank, _ = strconv.Atoi(value)
+		case "created":
+			if created, err := time.Parse(time.RFC3339, value); err == nil {
+				todo.created = created
+			}
+		case "completed":
+			todo.completed = value == "true"
+		case "attachment":
+			todo.attach(value)
+		}
+	}
+
+	todo.title = rest
+}
+
+// textKeys are the lines the text format can have between "completed: " and the
+// title. The title starts at the first line that isn't one of them
+var textKeys = []string{"uuid", "rank", "created", "attachment"}
+
+// isTextKeyLine tells if line is one of the textKeys lines, like "uuid: 8f14e45f-..."
+func isTextKeyLine(line string) bool {
+	key, _, found := strings.Cut(line, ": ")
+	return found && slices.Contains(textKeys, key)
+}
+
+func parseText(todo *Todo, rest string) {
+	state, rest, _ := strings.Cut(rest, "\n")
+	todo.completed = strings.TrimSpace(state) == "true"
+
+	for rest != "" {
+		line, after, _ := strings.Cut(rest, "\n")
+		if !isTextKeyLine(line) {
+			break
+		}
+		rest = after
+
+		key, value, _ := strings.Cut(line, ": ")
+		value = strings.TrimSpace(value)
+		switch key {
+		case "uuid":
+			todo.uuid = value
+		case "rank":
+			todo.rank, _ = strconv.Atoi(value)
+		case "created":
+			if created, err := time.Parse(time.RFC3339, value); err == nil {
+				todo.created = created
+			}
+		case "attachment":
+			todo.attach(value)
+		}
+	}
+
+	// editors like to add a newline at the end of the file
+	todo.title = strings.TrimSuffix(rest, "\n")
+}
+
+// readTodoState reads only as much of the file as needed to know if the todo
+// is completed, the title of the returned todo is empty or partial
+func readTodoState(filepath string, id TODOId) (*Todo, error) {
+	file, err := os.Open(filepath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrTodoNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	in := bufio.NewReader(file)
+
+	first, err := in.Peek(1)
+	if err == io.EOF {
+		return parseTodo(id, nil), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var data []byte
+
+	switch first[0] {
+	case headerFormatVersion:
+		// the version byte, then header lines up to and including the empty one
+		version, _ := in.ReadByte()
+		data = append(data, version)
+		for {
+			line, err := in.ReadString('\n')
+			data = append(data, line...)
+			if err != nil || line == "\n" {
+				break
+			}
+		}
+	case 'c':
+		// text format, the "completed: " line and the key lines after it. The title
+		// line that ends them is read too, the title is partial anyway
+		line, err := in.ReadString('\n')
+		data = []byte(line)
+		for err == nil && strings.HasPrefix(string(data), "completed: ") {
+			line, err = in.ReadString('\n')
+			data = append(data, line...)
+			if !isTextKeyLine(strings.TrimSuffix(line, "\n")) {
+				break
+			}
+		}
+	default:
+		data = first
+	}
+
+	return parseTodo(id, data), nil
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path"
+	"slices"
+	"testing"
+	"time"
+)
+
+func mustEncode(t *testing.T, todo *Todo, format string) []byte {
+	t.Helper()
+
+	data, err := encodeTodo(todo, format)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestHeaderRoundTrip(t *testing.T) {
+	todo := &Todo{
+		id:          5,
+		uuid:        "8f14e45f-ceea-4e6b-9b1a-3c2d5a6e7f80",
+		created:     time.Date(2024, 5, 1, 9, 30, 0, 0, time.FixedZone("", 2*60*60)),
+		completed:   true,
+		title:       "buy milk\nand eggs",
+		attachments: []string{"/home/me/shopping.txt"},
+	}
+
+	parsed := parseTodo(5, mustEncode(t, todo, formatHeader))
+
+	if parsed.uuid != todo.uuid || !parsed.created.Equal(todo.created) || parsed.completed != todo.completed ||
+		parsed.title != todo.title || !slices.Equal(parsed.attachments, todo.attachments) {
+		t.Errorf("parsed %+v, want %+v", parsed, todo)
+	}
+}
+
+func TestHeaderUnknownKeys(t *testing.T) {
+	parsed := parseTodo(5, []byte("\x02completed=true\npriority=high\ncreated=yesterday\n\nbuy milk"))
+
+	if !parsed.completed || parsed.title != "buy milk" || !parsed.created.IsZero() {
+		t.Errorf("parsed %+v, want unknown keys and a bad created skipped", parsed)
+	}
+}
+
+func TestUUIDStableAcrossEdits(t *testing.T) {
+	useTestDir(t)
+
+	todo, err := addTodo("buy milk")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if todo.uuid == "" {
+		t.Fatal("new todo has no uuid")
+	}
+
+	for _, title := range []string{"buy oat milk", "buy milk and eggs"} {
+		err := store.Update(todo.id, func(todo *Todo) error {
+			todo.update(title)
+			todo.complete()
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		loaded, err := LoadTodo(todo.id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if loaded.uuid != todo.uuid {
+			t.Fatalf("uuid after editing to %q = %q, want %q", title, loaded.uuid, todo.uuid)
+		}
+	}
+}
+
+func TestUUIDGivenToOlderFormatsOnce(t *testing.T) {
+	dir := useTestDir(t)
+	config.format = formatText
+	if err := os.WriteFile(path.Join(dir, "5"), []byte("completed: false\nbuy milk\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	uuids := []string{}
+	for _, title := range []string{"buy oat milk", "buy milk and eggs"} {
+		err := store.Update(5, func(todo *Todo) error {
+			todo.update(title)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		data, err := os.ReadFile(path.Join(dir, "5"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if format := detectFormat(data); format != formatText {
+			t.Fatalf("saved in the %s format with format = text: %q", format, data)
+		}
+
+		loaded, err := LoadTodo(5)
+		if err != nil {
+			t.Fatal(err)
+		}
+		uuids = append(uuids, loaded.uuid)
+	}
+
+	if uuids[0] == "" || uuids[0] != uuids[1] {
+		t.Errorf("uuids after two edits = %q, want the same one kept", uuids)
+	}
+}
+
+func TestTextRoundTrip(t *testing.T) {
+	todos := []*Todo{
+		{
+			id:          5,
+			uuid:        "8f14e45f-ceea-4e6b-9b1a-3c2d5a6e7f80",
+			rank:        3,
+			created:     time.Date(2024, 5, 1, 9, 30, 0, 0, time.FixedZone("", 2*60*60)),
+			completed:   true,
+			title:       "buy milk\nand eggs",
+			attachments: []string{"/home/me/shopping.txt", "/home/me/recipe.pdf"},
+		},
+		{id: 6, title: "walk dog"},
+		// only known keys end the key lines, so this stays the title
+		{id: 7, uuid: "0b7e9a1c-2f4d-4e8a-9c3b-5d6e7f8a9b0c", title: "note: call mom"},
+	}
+
+	for _, todo := range todos {
+		data := mustEncode(t, todo, formatText)
+		if format := detectFormat(data); format != formatText {
+			t.Fatalf("encoded %q is detected as %s", data, format)
+		}
+
+		parsed := parseTodo(todo.id, data)
+		if !sameTodo(parsed, todo) {
+			t.Errorf("parsed %q as %+v, want %+v", data, parsed, todo)
+		}
+	}
+}
+
+func TestTextHandEdited(t *testing.T) {
+	// the old text format, and one an editor saved with CRLF and extra spaces
+	tests := []struct {
+		data string
+		want Todo
+	}{
+		{"completed: true\nbuy milk\n", Todo{completed: true, title: "buy milk"}},
+		{"completed: false\nbuy milk", Todo{title: "buy milk"}},
+		{"completed: false\r\nuuid:  8f14e45f \r\nrank: 4\r\nbuy milk", Todo{uuid: "8f14e45f", rank: 4, title: "buy milk"}},
+		{"completed: false\ncreated: yesterday\nbuy milk\n", Todo{title: "buy milk"}},
+	}
+
+	for _, test := range tests {
+		parsed := parseTodo(5, []byte(test.data))
+		if parsed.uuid != test.want.uuid || parsed.rank != test.want.rank || parsed.completed != test.want.completed ||
+			!parsed.created.IsZero() || parsed.title != test.want.title {
+			t.Errorf("parsed %q as %+v, want %+v", test.data, parsed, test.want)
+		}
+	}
+}
+
+func TestReadTodoStateText(t *testing.T) {
+	useTestDir(t)
+
+	todo := &Todo{id: 5, uuid: "8f14e45f-ceea-4e6b-9b1a-3c2d5a6e7f80", rank: 3, completed: true,
+		title: "buy milk", attachments: []string{"/home/me/shopping.txt"}}
+	writeTodoFile(t, "5", string(mustEncode(t, todo, formatText)), time.Now())
+
+	state, err := readTodoState(getFilePath(5), 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !state.completed || state.rank != 3 || state.uuid != todo.uuid {
+		t.Errorf("readTodoState = %+v, want the completed state, rank and uuid", state)
+	}
+}
+
+func TestEncodeRefusesLineBreaks(t *testing.T) {
+	todos := []*Todo{
+		{id: 5, title: "inj", attachments: []string{"/nope\n\nhijacked title"}},
+		{id: 5, title: "inj", attachments: []string{"a\ncompleted=true"}},
+		{id: 5, title: "inj", attachments: []string{"a\rb"}},
+		{id: 5, title: "inj", uuid: "8f14e45f\nrank=1"},
+	}
+
+	for _, todo := range todos {
+		for _, format := range []string{formatHeader, formatText} {
+			if _, err := encodeTodo(todo, format); !errors.Is(err, ErrLineBreakInValue) {
+				t.Errorf("encodeTodo(%+v, %s) error = %v, want ErrLineBreakInValue", todo, format, err)
+			}
+		}
+	}
+
+	// a title with several lines is fine, it's the last thing in the file
+	if _, err := encodeTodo(&Todo{id: 5, title: "buy milk\nand eggs"}, formatHeader); err != nil {
+		t.Errorf("encodeTodo with a multiline title: %v", err)
+	}
+}
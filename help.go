@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	helpOrderFixed = "fixed"
+	helpOrderUsage = "usage"
+)
+
+type menuAction struct {
+	number int
+	label  string
+	// changes todos or templates, so it's disabled in read-only mode
+	changes bool
+}
+
+var menuActions = []menuAction{
+	{1, "List all TODOs", false},
+	{2, "Add new TODO", true},
+	{3, "Complete TODO", true},
+	{4, "Uncomplete TODO", true},
+	{5, "Delete TODO", true},
+	{6, "List completed TODOs", false},
+	{7, "List uncompleted TODOs", false},
+	{8, "Edit TODO", true},
+	{9, "Show this help", false},
+	{10, "Triage uncompleted TODOs", true},
+	{11, "Complete TODO by name", true},
+	{12, "Show TODO", false},
+	{13, "Attach file to TODO", true},
+	{14, "Open TODO attachment", false},
+	{15, "Create TODOs from template", true},
+	{16, "Create or edit template", true},
+	{17, "Print codes of uncompleted TODOs", false},
+	{18, "List TODOs created between two days", false},
+}
+
+// isChangingAction tells if the menu action number is one that changes todos
+func isChangingAction(number int) bool {
+	for _, action := range menuActions {
+		if action.number == number {
+			return action.changes
+		}
+	}
+	return false
+}
+
+// usage counts how many times each action was picked, by action number
+var usage = map[int]int{}
+
+func printHelp() {
+	actions := slices.Clone(menuActions)
+	if config.helpOrder == helpOrderUsage {
+		// most used first, the numbers stay the same so only the order changes
+		slices.SortStableFunc(actions, func(a, b menuAction) int {
+			return usage[b.number] - usage[a.number]
+		})
+	}
+
+	fmt.Println(accent("Select action:"))
+	for _, action := range actions {
+		if action.changes && isReadOnly() {
+			fmt.Printf("%d: %s (disabled, read-only)\n", action.number, action.label)
+			continue
+		}
+		fmt.Printf("%d: %s\n", action.number, action.label)
+	}
+	fmt.Print(
+		"0: Exit\n" +
+			".: Repeat the last action with the same answers\n" +
+			"When asked for a todo, type ? or /text to pick it from a list, or its code\n",
+	)
+}
+
+func getUsagePath() string {
+	return path.Join(getConfigDir(), "usage")
+}
+
+func countUsage(action int) {
+	usage[action]++
+
+	if config.rememberUsage {
+		saveUsage()
+	}
+}
+
+// loadUsage reads the counts saved by earlier sessions, one "action count" per line
+func loadUsage() {
+	file, err := os.Open(getUsagePath())
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			fmt.Fprintf(os.Stderr, "Error reading usage: %+v\n", err)
+		}
+		return
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		actionRaw, countRaw, _ := strings.Cut(scanner.Text(), " ")
+		action, err := strconv.Atoi(actionRaw)
+		if err != nil {
+			continue
+		}
+		count, err := strconv.Atoi(countRaw)
+		if err != nil {
+			continue
+		}
+		usage[action] = count
+	}
+}
+
+func saveUsage() {
+	var lines strings.Builder
+	for _, action := range menuActions {
+		if count := usage[action.number]; count > 0 {
+			fmt.Fprintf(&lines, "%d %d\n", action.number, count)
+		}
+	}
+
+	if err := os.WriteFile(getUsagePath(), []byte(lines.String()), 0666); err != nil {
+		fmt.Fprintf(os.Stderr, "Error saving usage: %+v\n", err)
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"os"
+	"path"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestUsageErrorsGoToStderr(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("the config dir only follows XDG_CONFIG_HOME on linux")
+	}
+	home := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", home)
+
+	// the config dir being a file makes both reading and saving fail
+	if err := os.WriteFile(path.Join(home, appName), nil, 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	stdout, stderr := captureOutput(t, func() {
+		loadUsage()
+		saveUsage()
+	})
+
+	if stdout != "" {
+		t.Errorf("stdout = %q, want nothing", stdout)
+	}
+	for _, message := range []string{"Error reading usage", "Error saving usage"} {
+		if !strings.Contains(stderr, message) {
+			t.Errorf("stderr = %q, want %q", stderr, message)
+		}
+	}
+}
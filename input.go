@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+var interactiveFlag = flag.Bool("interactive", true, "with false, fail instead of asking for anything, for scripts")
+
+// inputRunes carries stdin one rune at a time, so the picker can read single
+// keys while the terminal is in raw mode and readLine can still build lines
+var inputRunes = make(chan rune, 64)
+
+// lines read while an action runs are recorded, so "." can replay the same answers to it
+var (
+	recorded []string
+	replay   []string
+)
+
+// stdin is read in its own goroutine so readLine can race it against the
+// inactivity timeout
+func startInput() {
+	go func() {
+		in := bufio.NewReader(os.Stdin)
+		for {
+			r, _, err := in.ReadRune()
+			if err != nil {
+				close(inputRunes)
+				return
+			}
+			inputRunes <- r
+		}
+	}()
+}
+
+// failNonInteractive stops the app when something would have to be asked
+// while --interactive=false, so scripts fail instead of hanging on stdin
+func failNonInteractive(what string) {
+	if *interactiveFlag {
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, "Error: %s, but --interactive=false\n", what)
+	os.Exit(1)
+}
+
+func readLine() string {
+	failNonInteractive("input is needed")
+
+	if len(replay) > 0 {
+		line := replay[0]
+		replay = replay[1:]
+		recorded = append(recorded, line)
+		fmt.Println(line)
+		return line
+	}
+
+	var line strings.Builder
+	for {
+		r, ok := readRune()
+		if !ok {
+			// a last line without a newline still counts
+			if line.Len() > 0 {
+				break
+			}
+			restoreTerminal()
+			fmt.Println("\nGoodbye!")
+			os.Exit(0)
+		}
+		if r == '\n' {
+			break
+		}
+		line.WriteRune(r)
+	}
+
+	text := strings.TrimRight(line.String(), "\r")
+	recorded = append(recorded, text)
+	return text
+}
+
+// restoreTerminal is set while stdin is in raw mode, so leaving on a timeout or
+// at the end of input doesn't leave the shell without echo
+var restoreTerminal = func() {}
+
+// readRune waits for the next rune of input, false at the end of input
+func readRune() (rune, bool) {
+	var timeout <-chan time.Time
+	if config.timeout > 0 {
+		timeout = time.After(config.timeout)
+	}
+
+	select {
+	case r, ok := <-inputRunes:
+		return r, ok
+	case <-timeout:
+		// every action saves as soon as it's done, so there is nothing left to flush here
+		restoreTerminal()
+		fmt.Println("\nSession timed out")
+		os.Exit(0)
+	}
+
+	return 0, false
+}
+
+// readRuneWithin is readRune for the rest of a key sequence, false if nothing comes in time
+func readRuneWithin(wait time.Duration) (rune, bool) {
+	select {
+	case r, ok := <-inputRunes:
+		return r, ok
+	case <-time.After(wait):
+		return 0, false
+	}
+}
+
+func replayInput(lines []string) {
+	replay = append([]string(nil), lines...)
+}
+
+// rerecord replaces what was recorded since mark with line, so "." replays the
+// outcome of the picker instead of the keys pressed in it
+func rerecord(mark int, line string) {
+	recorded = append(recorded[:mark], line)
+}
+
+// takeRecorded returns what was read since the last call and drops any replay leftovers
+func takeRecorded() []string {
+	lines := recorded
+	recorded = nil
+	replay = nil
+	return lines
+}
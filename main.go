@@ -1,24 +1,39 @@
 package main
 
 import (
-	"bufio"
+	"errors"
+	"flag"
 	"fmt"
 	"io"
+	"io/fs"
 	"log"
 	"math/rand"
 	"os"
 	"path"
-	"regexp"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type TODOId uint
 
+var ErrTodoNotFound = errors.New("todo not found")
+
+// ErrTodoIsDir is returned when something created a directory where a todo file should be
+var ErrTodoIsDir = errors.New("todo file is a directory")
+
+// ErrTodoDuplicated is returned for ids stored in several files with duplicates = skip
+var ErrTodoDuplicated = errors.New("todo is stored in several files")
+
 type Todo struct {
-	id        TODOId
-	completed bool
-	title     string
+	id          TODOId
+	uuid        string
+	rank        int
+	created     time.Time
+	completed   bool
+	title       string
+	attachments []string
 }
 
 func (todo *Todo) complete() {
@@ -33,248 +48,654 @@ func (todo *Todo) update(title string) {
 	todo.title = title
 }
 
-func (todo Todo) save() {
-	filepath := getFilePath(todo.id)
-	file, err := os.Create(filepath)
+func (todo *Todo) attach(path string) {
+	todo.attachments = append(todo.attachments, path)
+}
+
+// fillMissing gives todos saved by older versions the uuid and number new todos get
+// when they're created
+func (todo *Todo) fillMissing() error {
+	if todo.uuid == "" {
+		todo.uuid = newUUID()
+	}
+	if todo.rank == 0 {
+		rank, err := nextRank()
+		if err != nil {
+			return err
+		}
+		todo.rank = rank
+	}
 
+	return nil
+}
+
+func (todo Todo) save() error {
+	filepath, err := store.path(todo.id)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
-	defer file.Close()
 
-	completedByte := make([]byte, 1)
-	if todo.completed {
-		completedByte[0] = 0x1
-	} else {
-		completedByte[0] = 0x0
+	return writeTodo(filepath, &todo)
+}
+
+// writeTodo saves the todo to filepath in the configured format, with the uuid and
+// number it's missing. Commands that already know the file use it instead of save
+func writeTodo(filepath string, todo *Todo) error {
+	if err := todo.fillMissing(); err != nil {
+		return err
+	}
+	if err := reserveRank(todo.rank); err != nil {
+		return err
 	}
-	file.Write(completedByte)
 
-	titleBytes := []byte(todo.title)
-	file.Write(titleBytes)
+	data, err := encodeTodo(todo, config.format)
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(filepath, data)
 }
 
-func (todo Todo) delete() {
-	filepath := getFilePath(todo.id)
-	err := os.Remove(filepath)
+// writeFileAtomic writes to a temporary file next to filepath and renames it over,
+// so a crash halfway leaves the old todo instead of a truncated one
+func writeFileAtomic(filepath string, data []byte) error {
+	file, err := os.CreateTemp(path.Dir(filepath), ".save-")
+	if err != nil {
+		return err
+	}
+
+	_, err = file.Write(data)
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(file.Name(), 0644)
+	}
+	if err == nil {
+		err = os.Rename(file.Name(), filepath)
+	}
+
 	if err != nil {
-		fmt.Printf("Error deleting todo file %s: %+v\n", filepath, err)
+		os.Remove(file.Name())
 	}
+	return err
 }
 
-func (todo Todo) print() {
-	fmt.Printf("%d\t%s\n", todo.id, todo.title)
+func (todo Todo) delete() {
+	// every file of the id goes, otherwise an older duplicate would take its place
+	paths := []string{getFilePath(todo.id)}
+	if files := todoFilesFor(todo.id); len(files) > 0 {
+		paths = paths[:0]
+		for _, file := range files {
+			paths = append(paths, file.path)
+		}
+	}
+
+	for _, filepath := range paths {
+		if info, err := os.Stat(filepath); err == nil && info.IsDir() {
+			fmt.Printf("Error deleting todo file %s: %v\n", filepath, ErrTodoIsDir)
+			continue
+		}
+
+		err := os.Remove(filepath)
+		if err != nil {
+			fmt.Printf("Error deleting todo file %s: %+v\n", filepath, err)
+		}
+	}
 }
 
-func printHelp() {
-	fmt.Print(
-		"Select action:\n" +
-			"1: List all TODOs\n" +
-			"2: Add new TODO\n" +
-			"3: Complete TODO\n" +
-			"4: Uncomplete TODO\n" +
-			"5: Delete TODO\n" +
-			"6: List completed TODOs\n" +
-			"7: List uncompleted TODOs\n" +
-			"8: Edit TODO\n" +
-			"9: Show this help\n" +
-			"0: Exit\n",
-	)
+func (todo Todo) print() {
+	id := rankLabel(&todo)
+
+	attached := ""
+	if len(todo.attachments) > 0 {
+		attached = fmt.Sprintf(" [%d attached]", len(todo.attachments))
+	}
+
+	title := todo.title
+	if config.strikethrough && todo.completed {
+		title = terminal.style(ansiStrikethrough, title)
+	}
+
+	fmt.Printf("%s\t%s%s\n", id, title, attached)
 }
 
 func getTodoId() TODOId {
 	for {
-		var idRaw string
-		fmt.Print("Select todo: ")
-		fmt.Scanln(&idRaw)
+		fmt.Print(accent("Select todo: "))
+		mark := len(recorded)
+		idRaw := strings.TrimSpace(readLine())
+
+		// "?" opens the picker with every todo, "/milk" with the ones matching "milk"
+		if idRaw == "?" || strings.HasPrefix(idRaw, "/") {
+			id, picked := pickTodo(strings.TrimPrefix(strings.TrimPrefix(idRaw, "?"), "/"))
+			if !picked {
+				continue
+			}
+			// "." picks the same todo again, even if the list changed since
+			rerecord(mark, shortcode(id))
+			return id
+		}
 
-		idInt, err := strconv.Atoi(idRaw)
+		if id, ok := parseShortcode(idRaw); ok {
+			return id
+		}
+
+		// "#3" always means the todo numbered 3, plain numbers only with rank_ids on
+		rankRaw, isRank := strings.CutPrefix(idRaw, "#")
+		isRank = isRank || config.rankIds
+
+		idInt, err := strconv.Atoi(rankRaw)
 		if err != nil {
 			fmt.Printf("Error reading id: %+v\n", err)
 			continue
 		}
 
+		if isRank {
+			id, found := idForRank(idInt)
+			if !found {
+				fmt.Printf("No todo #%d\n", idInt)
+				continue
+			}
+
+			fmt.Printf("#%d (id %d)\n", idInt, id)
+			return id
+		}
+
 		id := TODOId(idInt)
 		return id
 	}
 }
 
 func getTodoTitle() string {
-	in := bufio.NewReader(os.Stdin)
-	title, _ := in.ReadString('\n')
-	return strings.Trim(title, "\n")
+	return readLine()
 }
 
 func getDirPath() string {
-	dir, err := os.Getwd()
+	return dataDir
+}
 
+// parseTodoFilename gets the id out of a todo file name. Only digits are accepted,
+// leading zeros included, and the id has to fit into a TODOId
+func parseTodoFilename(name string) (TODOId, bool) {
+	idInt, err := strconv.ParseUint(name, 10, strconv.IntSize)
 	if err != nil {
-		log.Fatal(err)
+		return 0, false
 	}
 
-	return path.Join(dir, "todos")
+	return TODOId(idInt), true
 }
 
+func todoFilename(id TODOId) string {
+	return strconv.FormatUint(uint64(id), 10)
+}
+
+// getFilePath is the canonical path of the todo, where new todos are saved. Existing
+// ones can be in a file with leading zeros, store.path finds the one in use
 func getFilePath(id TODOId) string {
 	dir := getDirPath()
-	filepath := path.Join(dir, strconv.Itoa(int(id)))
+	filepath := path.Join(dir, todoFilename(id))
 
 	return filepath
 }
 
 func LoadTodo(id TODOId) (*Todo, error) {
-	filepath := getFilePath(id)
+	filepath, err := store.path(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return loadTodoFile(filepath, id)
+}
+
+func loadTodoFile(filepath string, id TODOId) (*Todo, error) {
 	file, err := os.Open(filepath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrTodoNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
 	defer file.Close()
 
-	completedByte := make([]byte, 1)
-	data := make([]byte, 64)
+	// opening a directory works, reading it doesn't, so catch it here with a clearer error
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("%w: %s", ErrTodoIsDir, filepath)
+	}
 
-	// I couldn't find a way to read only first bit, so reading the first byte and checking it's value
-	file.Read(completedByte)
-	completed := completedByte[0]&0x1 == 0x1
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
 
-	title := ""
-	for {
-		n, err := file.Read(data)
-		if err == io.EOF {
-			break
-		}
-		title += string(data[:n])
+	return parseTodo(id, data), nil
+}
+
+// checkTodoLimit warns when one more todo would go over max_todos and
+// tells whether creating it is still allowed
+func checkTodoLimit() bool {
+	if config.maxTodos == 0 {
+		return true
 	}
 
-	return &Todo{
-		id:        id,
-		completed: completed,
-		title:     title,
-	}, nil
+	var filter func(*Todo) bool
+	if config.maxTodosExcludeCompleted {
+		filter = isPending
+	}
+	count := store.Count(filter)
+
+	if count < config.maxTodos {
+		return true
+	}
+
+	fmt.Printf("You have %d todos (limit %d). Consider clearing completed ones.\n", count, config.maxTodos)
+
+	return !config.maxTodosRefuse
+}
+
+func printTodoError(id TODOId, err error) {
+	if errors.Is(err, ErrTodoNotFound) {
+		fmt.Println("Todo not found")
+		return
+	}
+
+	fmt.Printf("Error with todo %d: %v\n", id, err)
 }
 
 func createTodoItem() {
+	if !checkTodoLimit() {
+		return
+	}
+
 	fmt.Print("title: ")
 	title := getTodoTitle()
 
+	if err := validateTitle(title); err != nil {
+		fmt.Printf("Invalid title: %v\n", err)
+		return
+	}
+
+	todo, err := addTodo(title)
+	if err != nil {
+		fmt.Printf("Error saving todo: %+v\n", err)
+		return
+	}
+	printSaved(todo)
+}
+
+func addTodo(title string) (*Todo, error) {
+	rank, err := nextRank()
+	if err != nil {
+		return nil, err
+	}
+
 	todo := &Todo{
-		id:        TODOId(rand.Intn(1000)),
+		id:        newTodoId(),
+		uuid:      newUUID(),
+		rank:      rank,
+		created:   time.Now().Truncate(time.Second),
 		title:     title,
 		completed: false,
 	}
-	todo.save()
+	if err := todo.save(); err != nil {
+		return nil, err
+	}
+	emitEvent("create", todo)
+
+	return todo, nil
+}
+
+// newTodoId picks a random id that isn't used yet
+func newTodoId() TODOId {
+	for tries := 0; tries < 10000; tries++ {
+		id := TODOId(rand.Intn(1000))
+		if !store.Exists(id) {
+			return id
+		}
+	}
+
+	log.Fatal("Couldn't find a free todo id, are there 1000 todos already?")
+	return 0
+}
+
+func printSaved(todo *Todo) {
+	if config.rankIds {
+		fmt.Printf("Saved as #%d (id %d)\n", todo.rank, todo.id)
+		return
+	}
 
 	fmt.Printf("Saved with id: %d\n", todo.id)
 }
 
 func changeTodoItemState(complete bool) {
 	id := getTodoId()
-	todo, err := LoadTodo(id)
+
+	var updated *Todo
+	err := store.Update(id, func(todo *Todo) error {
+		if complete {
+			todo.complete()
+		} else {
+			todo.uncomplete()
+		}
+		updated = todo
+		return nil
+	})
 
 	if err != nil {
-		fmt.Println("Todo not found")
+		printTodoError(id, err)
 		return
 	}
 
+	event := "uncomplete"
 	if complete {
-		todo.complete()
-	} else {
-		todo.uncomplete()
+		event = "complete"
 	}
-
-	todo.save()
+	emitEvent(event, updated)
 
 	fmt.Println("Todo updated")
 }
 
 func editTodo() {
 	id := getTodoId()
-	todo, err := LoadTodo(id)
+
+	var updated *Todo
+	reopened := false
+	err := store.Update(id, func(todo *Todo) error {
+		fmt.Printf("old title: %s\n", todo.title)
+		fmt.Print("new title: ")
+		title := getTodoTitle()
+
+		if err := validateTitle(title); err != nil {
+			return fmt.Errorf("invalid title: %w", err)
+		}
+
+		todo.update(title)
+
+		if config.reopenOnEdit && todo.completed {
+			todo.uncomplete()
+			reopened = true
+		}
+
+		updated = todo
+		return nil
+	})
 
 	if err != nil {
-		fmt.Println("Todo not found")
+		printTodoError(id, err)
 		return
 	}
 
-	fmt.Printf("old title: %s", todo.title)
-	fmt.Print("new title: ")
-	title := getTodoTitle()
-
-	todo.update(title)
-	todo.save()
+	emitEvent("edit", updated)
+	if reopened {
+		emitEvent("uncomplete", updated)
+		fmt.Println("Todo was completed, reopened it")
+	}
 
 	fmt.Println("Todo updated")
 }
 
+func showTodo() {
+	id := getTodoId()
+	todo, err := LoadTodo(id)
+
+	if err != nil {
+		printTodoError(id, err)
+		return
+	}
+
+	if config.rankIds && todo.rank > 0 {
+		fmt.Printf("#%d (id %d)\n", todo.rank, todo.id)
+	} else {
+		fmt.Printf("id %d\n", todo.id)
+	}
+	fmt.Printf("code: %s\n", shortcode(todo.id))
+	fmt.Printf("title: %s\n", todo.title)
+	fmt.Printf("completed: %t\n", todo.completed)
+
+	if len(todo.attachments) > 0 {
+		fmt.Println("attachments:")
+		printAttachments(todo)
+	}
+}
+
 func deleteTodo() {
 	id := getTodoId()
 	todo, err := LoadTodo(id)
 
 	if err != nil {
-		fmt.Println("Todo not found")
+		printTodoError(id, err)
 		return
 	}
 
 	todo.delete()
+	emitEvent("delete", todo)
 
 	fmt.Println("Todo deleted")
 }
 
-func loadAllTodos() []*Todo {
+type todoFile struct {
+	id       TODOId
+	path     string
+	modified time.Time
+}
+
+// todoDirs lists the directories named like todo files, they are skipped everywhere else
+func todoDirs() []string {
 	dir := getDirPath()
 	entries, err := os.ReadDir(dir)
 
+	// nothing was saved yet, commands that only read don't create the directory
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	todos := make([]*Todo, 0, len(entries))
+	dirs := []string{}
+	for _, entry := range entries {
+		if _, ok := parseTodoFilename(entry.Name()); ok && entry.IsDir() {
+			dirs = append(dirs, path.Join(dir, entry.Name()))
+		}
+	}
+
+	return dirs
+}
+
+// scanTodoFiles finds the files in the todos directory that are named like a todo.
+// Names with leading zeros mean the same id can show up more than once
+func scanTodoFiles() []todoFile {
+	dir := getDirPath()
+	entries, err := os.ReadDir(dir)
+
+	// nothing was saved yet, commands that only read don't create the directory
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	files := make([]todoFile, 0, len(entries))
 
 	for _, entry := range entries {
 		if entry.IsDir() {
 			continue
 		}
 
-		// can check using strconv, but I wanted to try regexp
-		match, _ := regexp.MatchString("^([0-9]+)$", entry.Name())
-		if !match {
+		id, ok := parseTodoFilename(entry.Name())
+		if !ok {
 			continue
 		}
 
-		idInt, err := strconv.Atoi(entry.Name())
+		info, err := entry.Info()
 		if err != nil {
 			continue
 		}
 
-		id := TODOId(idInt)
-		todo, err := LoadTodo(id)
+		// keeping the path of the file that is there, the name isn't always the canonical one
+		files = append(files, todoFile{id: id, path: path.Join(dir, entry.Name()), modified: info.ModTime()})
+	}
 
-		if err != nil {
+	return files
+}
+
+// duplicateTodoFiles groups the files by id, keeping only ids with more than one file
+func duplicateTodoFiles(files []todoFile) map[TODOId][]todoFile {
+	byId := make(map[TODOId][]todoFile, len(files))
+	for _, file := range files {
+		byId[file.id] = append(byId[file.id], file)
+	}
+
+	for id, group := range byId {
+		if len(group) < 2 {
+			delete(byId, id)
+		}
+	}
+
+	return byId
+}
+
+// todoIndex is every todo file by id, from one scan of the todos directory. Commands
+// that go through all todos use it instead of looking up each id again
+type todoIndex map[TODOId][]todoFile
+
+func indexTodoFiles() todoIndex {
+	index := todoIndex{}
+	for _, file := range scanTodoFiles() {
+		index[file.id] = append(index[file.id], file)
+	}
+	return index
+}
+
+// ids are the indexed ids in ascending order
+func (index todoIndex) ids() []TODOId {
+	ids := make([]TODOId, 0, len(index))
+	for id := range index {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+// path is Store.path without scanning the directory again
+func (index todoIndex) path(id TODOId) (string, error) {
+	return pathOf(id, index[id])
+}
+
+// pathOf picks the file of id from its files, see Store.path
+func pathOf(id TODOId, files []todoFile) (string, error) {
+	if len(files) == 0 {
+		return getFilePath(id), nil
+	}
+
+	file, ok := pickTodoFile(files)
+	if !ok {
+		return "", fmt.Errorf("%w, run check for details", ErrTodoDuplicated)
+	}
+
+	return file.path, nil
+}
+
+// todoFilesFor finds the files stored for one id, normally there's only one
+func todoFilesFor(id TODOId) []todoFile {
+	files := []todoFile{}
+	for _, file := range scanTodoFiles() {
+		if file.id == id {
+			files = append(files, file)
+		}
+	}
+
+	return files
+}
+
+// pickTodoFile chooses which of the files of one id is the todo, following the
+// duplicates config. With duplicates = skip there's none when there are several
+func pickTodoFile(group []todoFile) (todoFile, bool) {
+	if len(group) == 1 {
+		return group[0], true
+	}
+	if config.duplicates == duplicatesSkip {
+		return todoFile{}, false
+	}
+
+	newest := group[0]
+	for _, other := range group[1:] {
+		if other.modified.After(newest.modified) {
+			newest = other
+		}
+	}
+
+	return newest, true
+}
+
+// listTodoFiles gives one file per id, what happens to ids with several files
+// depends on the duplicates config. Skipped ids are reported on stderr
+func listTodoFiles() []todoFile {
+	return pickTodoFiles(true)
+}
+
+// pickTodoFiles is listTodoFiles, warn false leaves out the report of skipped ids
+func pickTodoFiles(warn bool) []todoFile {
+	files := scanTodoFiles()
+	duplicates := duplicateTodoFiles(files)
+
+	if len(duplicates) == 0 {
+		return files
+	}
+
+	unique := make([]todoFile, 0, len(files))
+	for _, file := range files {
+		group, duplicated := duplicates[file.id]
+		if !duplicated {
+			unique = append(unique, file)
 			continue
 		}
 
-		todos = append(todos, todo)
+		picked, ok := pickTodoFile(group)
+		if warn && !ok && file == group[0] {
+			fmt.Fprintf(os.Stderr, "Skipping todo %d, it's in %d files, run check for details\n", file.id, len(group))
+		}
+		if ok && file == picked {
+			unique = append(unique, file)
+		}
 	}
 
-	return todos
+	return unique
 }
 
-func listTodos(includeUncomplete, includeComplete bool) {
-	todos := loadAllTodos()
+func loadAllTodos() []*Todo {
+	files := listTodoFiles()
+	todos := make([]*Todo, 0, len(files))
 
-	completedTodos := make([]*Todo, 0, len(todos))
-	uncompletedTodos := make([]*Todo, 0, len(todos))
+	progress := newLoadProgress(len(files))
+	defer progress.done()
 
-	for _, todo := range todos {
-		if todo.completed {
-			completedTodos = append(completedTodos, todo)
-		} else {
-			uncompletedTodos = append(uncompletedTodos, todo)
+	for i, file := range files {
+		progress.update(i)
+		todo, err := loadTodoFile(file.path, file.id)
+
+		if err != nil {
+			continue
 		}
+
+		todos = append(todos, todo)
 	}
 
+	return todos
+}
+
+func listTodos(includeUncomplete, includeComplete bool) {
 	if includeUncomplete {
-		fmt.Printf("%d uncompleted todos:\n", len(uncompletedTodos))
+		uncompletedTodos := store.All(isPending)
+		fmt.Println(accent(fmt.Sprintf("%d uncompleted todos:", len(uncompletedTodos))))
 		for _, todo := range uncompletedTodos {
 			todo.print()
 		}
@@ -285,26 +706,234 @@ func listTodos(includeUncomplete, includeComplete bool) {
 	}
 
 	if includeComplete {
-		fmt.Printf("%d completed todos:\n", len(completedTodos))
+		completedTodos := store.All(isCompleted)
+		fmt.Println(accent(fmt.Sprintf("%d completed todos:", len(completedTodos))))
 		for _, todo := range completedTodos {
 			todo.print()
 		}
 	}
 }
 
+// listCreatedTodos lists the todos created from one day to another, both included,
+// in the order they were created
+func listCreatedTodos() {
+	fmt.Print("from (YYYY-MM-DD, today, yesterday or -7d): ")
+	from, err := parseDate(readLine())
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	fmt.Print("to: ")
+	to, err := parseDate(readLine())
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	// up to the end of that day
+	to = to.AddDate(0, 0, 1)
+
+	skipped := 0
+	todos := store.All(func(todo *Todo) bool {
+		if todo.created.IsZero() {
+			skipped++
+			return false
+		}
+		return !todo.created.Before(from) && todo.created.Before(to)
+	})
+	slices.SortStableFunc(todos, func(a, b *Todo) int {
+		return a.created.Compare(b.created)
+	})
+
+	fmt.Println(accent(fmt.Sprintf("%d todos created:", len(todos))))
+	for _, todo := range todos {
+		fmt.Printf("%s  ", todo.created.Local().Format(time.DateOnly))
+		todo.print()
+	}
+
+	if skipped > 0 {
+		fmt.Printf("%d todos skipped, they don't know when they were created\n", skipped)
+	}
+}
+
+func triageTodos() {
+	pending := store.All(isPending)
+
+	if len(pending) == 0 {
+		fmt.Println("Nothing to triage")
+		return
+	}
+
+	fmt.Print("c: complete, e: edit, d: delete, s: skip, q: quit\n")
+
+	for i := 0; i < len(pending); {
+		todo := pending[i]
+		todo.print()
+
+		fmt.Print(accent("triage> "))
+		choice := strings.TrimSpace(readLine())
+
+		switch choice {
+		case "c":
+			todo.complete()
+			if err := todo.save(); err != nil {
+				fmt.Printf("Error saving todo: %+v\n", err)
+				continue
+			}
+			emitEvent("complete", todo)
+			fmt.Println("Todo completed")
+		case "e":
+			fmt.Print("new title: ")
+			title := getTodoTitle()
+			if err := validateTitle(title); err != nil {
+				fmt.Printf("Invalid title: %v\n", err)
+				continue
+			}
+			todo.update(title)
+			if err := todo.save(); err != nil {
+				fmt.Printf("Error saving todo: %+v\n", err)
+				continue
+			}
+			emitEvent("edit", todo)
+			fmt.Println("Todo updated")
+		case "d":
+			todo.delete()
+			emitEvent("delete", todo)
+			fmt.Println("Todo deleted")
+		case "s":
+			fmt.Println("Skipped")
+		case "q":
+			fmt.Printf("%d of %d triaged\n", i, len(pending))
+			return
+		default:
+			fmt.Println("Unknown choice")
+			continue
+		}
+
+		i++
+		fmt.Printf("%d of %d triaged\n", i, len(pending))
+	}
+}
+
+func hasTitlePrefix(todo *Todo, prefix string) bool {
+	return strings.HasPrefix(strings.ToLower(todo.title), strings.ToLower(prefix))
+}
+
+func completeTodoByName() {
+	fmt.Print("title starts with: ")
+	prefix := getTodoTitle()
+
+	if prefix == "" {
+		fmt.Println("Prefix can't be empty")
+		return
+	}
+
+	matches := store.All(and(isPending, titleStartsWith(prefix)))
+
+	var todo *Todo
+
+	switch len(matches) {
+	case 0:
+		fmt.Printf("No uncompleted todo starts with %q\n", prefix)
+		return
+	case 1:
+		todo = matches[0]
+	default:
+		fmt.Printf("%d todos match:\n", len(matches))
+		for _, match := range matches {
+			match.print()
+		}
+
+		id := getTodoId()
+		for _, match := range matches {
+			if match.id == id {
+				todo = match
+			}
+		}
+
+		if todo == nil {
+			fmt.Println("Todo not found")
+			return
+		}
+	}
+
+	todo.complete()
+	if err := todo.save(); err != nil {
+		fmt.Printf("Error saving todo: %+v\n", err)
+		return
+	}
+	emitEvent("complete", todo)
+
+	fmt.Printf("Completed: %s\n", todo.title)
+}
+
 func main() {
-	fmt.Println("Simple CLI TODO app")
+	flag.Parse()
+
+	order, err := parseSort(*sortFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid --sort: %v\n", err)
+		os.Exit(2)
+	}
+	if *reverseFlag {
+		order = reverse(order)
+	}
+	todoOrder = order
+
+	initDirs()
+	terminal = detectTerminal(os.Stdout)
+	stderrTerminal = detectTerminal(os.Stderr)
+
+	if flag.NArg() > 0 {
+		os.Exit(runCommand(flag.Args()))
+	}
+
+	failNonInteractive("no command given and the menu is interactive")
+
+	if isReadOnly() {
+		requireReadable()
+	} else {
+		requireWritable()
+	}
+
+	startInput()
+
+	if config.rememberUsage {
+		loadUsage()
+	}
+
+	fmt.Println(accent("Simple CLI TODO app"))
 
 	printHelp()
 
+	lastAction := -1
+	var lastInput []string
+
 	for {
-		var actionRaw string
-		fmt.Print("> ")
-		fmt.Scanln(&actionRaw)
-		action, err := strconv.Atoi(actionRaw)
+		fmt.Print(accent("> "))
+		actionRaw := strings.TrimSpace(readLine())
+		takeRecorded()
+
+		var action int
+		if actionRaw == "." {
+			if lastAction == -1 {
+				fmt.Println("No previous action to repeat")
+				continue
+			}
+			action = lastAction
+			replayInput(lastInput)
+		} else {
+			var err error
+			action, err = strconv.Atoi(actionRaw)
 
-		if err != nil {
-			fmt.Printf("Error reading action: %+v\n", err)
+			if err != nil {
+				fmt.Printf("Error reading action: %+v\n", err)
+				continue
+			}
+		}
+
+		if isChangingAction(action) && isReadOnly() {
+			fmt.Printf("Not available: %v\n", ErrReadOnly)
 			continue
 		}
 
@@ -327,11 +956,34 @@ func main() {
 			editTodo()
 		case 9:
 			printHelp()
+		case 10:
+			triageTodos()
+		case 11:
+			completeTodoByName()
+		case 12:
+			showTodo()
+		case 13:
+			attachFile()
+		case 14:
+			openAttachment()
+		case 15:
+			useTemplate()
+		case 16:
+			editTemplate()
+		case 17:
+			printShortcodes()
+		case 18:
+			listCreatedTodos()
 		case 0:
 			fmt.Println("Goodbye!")
 			os.Exit(0)
 		default:
 			fmt.Println("Unknown action")
+			continue
 		}
+
+		countUsage(action)
+		lastAction = action
+		lastInput = takeRecorded()
 	}
 }
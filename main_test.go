@@ -0,0 +1,172 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// useTestDir points the app at an empty todos directory with the default config
+func useTestDir(t *testing.T) string {
+	t.Helper()
+
+	config = loadConfig(t.TempDir())
+	dataDir = t.TempDir()
+
+	return dataDir
+}
+
+func TestLoadTodoMissingFile(t *testing.T) {
+	useTestDir(t)
+
+	_, err := LoadTodo(5)
+	if !errors.Is(err, ErrTodoNotFound) {
+		t.Fatalf("LoadTodo(5) error = %v, want ErrTodoNotFound", err)
+	}
+}
+
+func TestLoadTodoUnreadableFile(t *testing.T) {
+	dir := useTestDir(t)
+
+	// a file in place of a directory, opening "blocker/5" fails but not with not found
+	blocker := path.Join(dir, "blocker")
+	if err := os.WriteFile(blocker, nil, 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := loadTodoFile(path.Join(blocker, "5"), 5)
+	if err == nil || errors.Is(err, ErrTodoNotFound) {
+		t.Fatalf("loadTodoFile error = %v, want an error other than ErrTodoNotFound", err)
+	}
+}
+
+func TestLoadTodoNoPermission(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("root can read files without permission")
+	}
+	dir := useTestDir(t)
+
+	if err := os.WriteFile(path.Join(dir, "5"), []byte("\x00buy milk"), 0000); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := LoadTodo(5)
+	if err == nil || errors.Is(err, ErrTodoNotFound) {
+		t.Fatalf("LoadTodo(5) error = %v, want an error other than ErrTodoNotFound", err)
+	}
+}
+
+func TestParseTodoFilename(t *testing.T) {
+	maxId := ^TODOId(0)
+	maxName := strconv.FormatUint(uint64(maxId), 10)
+
+	tests := []struct {
+		name string
+		id   TODOId
+		ok   bool
+	}{
+		{"5", 5, true},
+		{"0", 0, true},
+		{"05", 5, true},
+		{"007", 7, true},
+		{maxName, maxId, true},
+		// one digit more overflows
+		{maxName + "0", 0, false},
+		{"99999999999999999999999", 0, false},
+		{"", 0, false},
+		{"abc", 0, false},
+		{"5a", 0, false},
+		{"-5", 0, false},
+		{"+5", 0, false},
+		{" 5", 0, false},
+		{"5.txt", 0, false},
+		{".write-check-123", 0, false},
+	}
+
+	for _, test := range tests {
+		id, ok := parseTodoFilename(test.name)
+		if id != test.id || ok != test.ok {
+			t.Errorf("parseTodoFilename(%q) = %d, %t, want %d, %t", test.name, id, ok, test.id, test.ok)
+		}
+	}
+}
+
+func TestTodoFilename(t *testing.T) {
+	for _, id := range []TODOId{0, 5, 42, 999, ^TODOId(0)} {
+		name := todoFilename(id)
+		parsed, ok := parseTodoFilename(name)
+		if !ok || parsed != id {
+			t.Errorf("parseTodoFilename(todoFilename(%d)) = %d, %t, want %d", id, parsed, ok, id)
+		}
+	}
+
+	if name := todoFilename(5); name != "5" {
+		t.Errorf("todoFilename(5) = %q, want no leading zeros", name)
+	}
+}
+
+func TestLeadingZerosFileCanBeChanged(t *testing.T) {
+	dir := useTestDir(t)
+
+	if err := os.WriteFile(path.Join(dir, "05"), []byte("\x00buy milk"), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	if !store.Exists(5) {
+		t.Error("Exists(5) = false with a 05 file")
+	}
+
+	err := store.Update(5, func(todo *Todo) error {
+		todo.complete()
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("completing the 05 todo: %v", err)
+	}
+
+	files := scanTodoFiles()
+	if len(files) != 1 || path.Base(files[0].path) != "05" {
+		t.Fatalf("todo files after the update: %v, want only 05", files)
+	}
+
+	todo, err := LoadTodo(5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !todo.completed {
+		t.Error("the 05 todo isn't completed")
+	}
+}
+
+func TestTodoDirectory(t *testing.T) {
+	dir := useTestDir(t)
+
+	// empty, so a plain os.Remove would take it with it
+	dirPath := path.Join(dir, "5")
+	if err := os.Mkdir(dirPath, 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeTodoFile(t, "6", "\x00buy milk", time.Now())
+
+	if _, err := LoadTodo(5); !errors.Is(err, ErrTodoIsDir) {
+		t.Errorf("LoadTodo error = %v, want ErrTodoIsDir", err)
+	}
+
+	if dirs := todoDirs(); !slices.Equal(dirs, []string{dirPath}) {
+		t.Errorf("todoDirs() = %v, want [%s]", dirs, dirPath)
+	}
+	for _, file := range scanTodoFiles() {
+		if file.id == 5 {
+			t.Errorf("scanTodoFiles() lists the directory %s", file.path)
+		}
+	}
+
+	(&Todo{id: 5}).delete()
+	if info, err := os.Stat(dirPath); err != nil || !info.IsDir() {
+		t.Errorf("delete removed the directory: %v", err)
+	}
+}
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// pickerSize is how many matches the picker shows at once
+const pickerSize = 10
+
+// fuzzyMatch tells if all runes of query appear in title in the same order, ignoring case
+func fuzzyMatch(query, title string) bool {
+	title = strings.ToLower(title)
+
+	for _, r := range strings.ToLower(query) {
+		i := strings.IndexRune(title, r)
+		if i < 0 {
+			return false
+		}
+		title = title[i+len(string(r)):]
+	}
+
+	return true
+}
+
+// pickerMatches is the todos matching query, titles starting with it first, and
+// how many matched before cutting the list to pickerSize
+func pickerMatches(todos []*Todo, query string) ([]*Todo, int) {
+	matches := make([]*Todo, 0, len(todos))
+	for _, todo := range todos {
+		if fuzzyMatch(query, todo.title) {
+			matches = append(matches, todo)
+		}
+	}
+
+	// titles starting with the query go first
+	slices.SortStableFunc(matches, func(a, b *Todo) int {
+		aPrefix, bPrefix := hasTitlePrefix(a, query), hasTitlePrefix(b, query)
+		if aPrefix == bPrefix {
+			return 0
+		}
+		if aPrefix {
+			return -1
+		}
+		return 1
+	})
+
+	total := len(matches)
+	if total > pickerSize {
+		matches = matches[:pickerSize]
+	}
+	return matches, total
+}
+
+// pickTodo narrows the todos down to the ones matching what's typed and lets the
+// user choose one. On a terminal it reacts to every key and the arrows move the
+// selection, otherwise the todos are numbered and picked by number. It returns
+// false when cancelled
+func pickTodo(query string) (TODOId, bool) {
+	failNonInteractive("picking a todo")
+
+	todos := store.All(nil)
+
+	// replayed answers are lines, so "." always goes through the numbered picker
+	if terminal.tty && isTerminalFile(os.Stdin) && len(replay) == 0 {
+		if restore, err := enableRawMode(); err == nil {
+			restoreTerminal = restore
+			defer func() {
+				restore()
+				restoreTerminal = func() {}
+			}()
+
+			return pickByKeys(todos, query)
+		}
+	}
+
+	return pickByNumber(todos, query)
+}
+
+func pickByNumber(todos []*Todo, query string) (TODOId, bool) {
+	for {
+		matches, total := pickerMatches(todos, query)
+
+		if total > len(matches) {
+			fmt.Printf("%d matches, showing first %d\n", total, pickerSize)
+		}
+		if len(matches) == 0 {
+			fmt.Println("No matches")
+		}
+
+		for i, todo := range matches {
+			line := fmt.Sprintf("%d) %s", i+1, todo.title)
+			fmt.Println(terminal.truncate(line))
+		}
+
+		fmt.Print("type to filter, number to select, empty to cancel: ")
+		input := strings.TrimSpace(readLine())
+
+		if input == "" {
+			return 0, false
+		}
+
+		if n, err := strconv.Atoi(input); err == nil {
+			if n < 1 || n > len(matches) {
+				fmt.Printf("No match number %d\n", n)
+				continue
+			}
+			return matches[n-1].id, true
+		}
+
+		query = input
+	}
+}
+
+const (
+	keyCtrlC     = 0x03
+	keyCtrlD     = 0x04
+	keyBackspace = 0x08
+	keyCtrlU     = 0x15
+	keyEscape    = 0x1b
+	keyDelete    = 0x7f
+
+	// the rest of an arrow key comes right after the escape, a lone escape doesn't
+	escapeWait = 50 * time.Millisecond
+)
+
+// pickByKeys runs the picker with stdin in raw mode: typing filters, up and down
+// move the selection, enter picks it and escape cancels
+func pickByKeys(todos []*Todo, query string) (TODOId, bool) {
+	selected := 0
+	drawn := 0
+
+	for {
+		matches, total := pickerMatches(todos, query)
+		selected = min(selected, max(len(matches)-1, 0))
+		drawn = drawPicker(drawn, matches, total, selected, query)
+
+		r, ok := readRune()
+		if !ok {
+			fmt.Println()
+			return 0, false
+		}
+
+		switch {
+		case r == '\r' || r == '\n':
+			if len(matches) == 0 {
+				continue
+			}
+			fmt.Println()
+			return matches[selected].id, true
+		case r == keyCtrlC || r == keyCtrlD:
+			fmt.Println()
+			return 0, false
+		case r == keyEscape:
+			next, ok := readRuneWithin(escapeWait)
+			if !ok {
+				fmt.Println()
+				return 0, false
+			}
+			if next != '[' && next != 'O' {
+				continue
+			}
+			switch arrow, _ := readRuneWithin(escapeWait); arrow {
+			case 'A':
+				selected = max(selected-1, 0)
+			case 'B':
+				selected = min(selected+1, max(len(matches)-1, 0))
+			}
+		case r == keyBackspace || r == keyDelete:
+			if query != "" {
+				runes := []rune(query)
+				query = string(runes[:len(runes)-1])
+				selected = 0
+			}
+		case r == keyCtrlU:
+			query = ""
+			selected = 0
+		case unicode.IsPrint(r):
+			query += string(r)
+			selected = 0
+		}
+	}
+}
+
+// drawPicker replaces the previous drawnLines lines with the picker and returns
+// how many lines above the cursor it now takes
+func drawPicker(drawnLines int, matches []*Todo, total, selected int, query string) int {
+	if drawnLines > 0 {
+		fmt.Printf("\x1b[%dA", drawnLines)
+	}
+	fmt.Print("\r\x1b[J")
+
+	lines := 0
+	if total > len(matches) {
+		fmt.Printf("%d matches, showing first %d\n", total, pickerSize)
+		lines++
+	}
+	if len(matches) == 0 {
+		fmt.Println("No matches")
+		lines++
+	}
+
+	for i, todo := range matches {
+		line := terminal.truncate("  " + todo.title)
+		if i == selected {
+			line = terminal.style(ansiReverse, terminal.truncate("> "+todo.title))
+		}
+		fmt.Println(line)
+		lines++
+	}
+
+	fmt.Print("type to filter, arrows to select, enter to pick, esc to cancel: " + query)
+
+	return lines
+}
+
+// isTerminalFile tells if file is a terminal rather than a pipe or a file
+func isTerminalFile(file *os.File) bool {
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// enableRawMode makes stdin hand over every key as it's pressed, without echo, and
+// returns how to put the terminal back. There is no raw mode in the standard library,
+// so it goes through stty like a shell script would
+func enableRawMode() (func(), error) {
+	saved, err := stty("-g")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := stty("-icanon", "-echo", "-isig", "min", "1", "time", "0"); err != nil {
+		return nil, err
+	}
+
+	return func() {
+		stty(strings.TrimSpace(saved))
+	}, nil
+}
+
+func stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("stty %s: %w", strings.Join(args, " "), err)
+	}
+	return string(out), nil
+}
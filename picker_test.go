@@ -0,0 +1,92 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestPickerMatches(t *testing.T) {
+	todos := []*Todo{
+		{id: 1, title: "buy milk"},
+		{id: 2, title: "walk dog"},
+		{id: 3, title: "Milk the cow"},
+		{id: 4, title: "mail kim"},
+	}
+
+	matches, total := pickerMatches(todos, "milk")
+	got := []TODOId{}
+	for _, todo := range matches {
+		got = append(got, todo.id)
+	}
+
+	// "Milk the cow" starts with the query, "mail kim" only has its runes in order
+	want := []TODOId{3, 1, 4}
+	if total != len(want) || !slices.Equal(got, want) {
+		t.Errorf("pickerMatches(milk) = %v of %d, want %v", got, total, want)
+	}
+}
+
+func TestPickerMatchesCutsToPickerSize(t *testing.T) {
+	var todos []*Todo
+	for i := 1; i <= pickerSize+5; i++ {
+		todos = append(todos, &Todo{id: TODOId(i), title: "todo"})
+	}
+
+	matches, total := pickerMatches(todos, "")
+	if len(matches) != pickerSize || total != pickerSize+5 {
+		t.Errorf("pickerMatches gave %d of %d, want %d of %d", len(matches), total, pickerSize, pickerSize+5)
+	}
+}
+
+// pressKeys makes the picker read keys as if they were typed in raw mode
+func pressKeys(t *testing.T, keys string) {
+	t.Helper()
+
+	saved := inputRunes
+	inputRunes = make(chan rune, len(keys))
+	for _, r := range keys {
+		inputRunes <- r
+	}
+	close(inputRunes)
+	t.Cleanup(func() { inputRunes = saved })
+}
+
+func TestPickByKeys(t *testing.T) {
+	useTestDir(t)
+	todos := []*Todo{
+		{id: 1, title: "buy milk"},
+		{id: 2, title: "walk dog"},
+		{id: 3, title: "milk the cow"},
+	}
+
+	tests := []struct {
+		name   string
+		keys   string
+		want   TODOId
+		picked bool
+	}{
+		{"enter picks the first", "\r", 1, true},
+		{"down arrow", "\x1b[B\r", 2, true},
+		{"down stops at the last", "\x1b[B\x1b[B\x1b[B\x1b[B\r", 3, true},
+		{"up stops at the first", "\x1b[A\x1b[B\x1b[A\x1b[A\r", 1, true},
+		{"typing filters", "dog\r", 2, true},
+		{"typing resets the selection", "\x1b[Bmilk\r", 3, true},
+		{"backspace", "dogx\x7f\r", 2, true},
+		{"ctrl-u clears the filter", "dog\x15\x1b[B\r", 2, true},
+		{"enter without matches waits", "zzz\r\x7f\x7f\x7f\r", 1, true},
+		{"escape cancels", "\x1b", 0, false},
+		{"ctrl-c cancels", "\x03", 0, false},
+		{"end of input cancels", "dog", 0, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pressKeys(t, test.keys)
+
+			id, picked := pickByKeys(todos, "")
+			if id != test.want || picked != test.picked {
+				t.Errorf("pickByKeys(%q) = %d, %t, want %d, %t", test.keys, id, picked, test.want, test.picked)
+			}
+		})
+	}
+}
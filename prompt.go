@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// promptCommand prints a short status for the shell prompt, like "⟶ 3" for 3 uncompleted
+// todos, and nothing when there are none. It runs on every prompt, so only the state at
+// the start of each file is read, never the titles. In ~/.bashrc:
+//
+//	PS1='$(todo-app prompt) \w \$ '
+//
+// or for zsh, with setopt PROMPT_SUBST:
+//
+//	PROMPT='$(todo-app prompt) %~ %# '
+func promptCommand() int {
+	pending := store.Count(isPending)
+	if pending == 0 {
+		return 0
+	}
+
+	// the shell captures stdout, the segment is shown on the terminal stderr is on
+	fmt.Print(promptSegment(stderrTerminal, pending))
+
+	return 0
+}
+
+func promptSegment(term Terminal, pending int) string {
+	format := config.promptFormat
+	if format == "" {
+		format = "⟶ {pending}"
+		if !term.utf8 {
+			format = "-> {pending}"
+		}
+	}
+
+	return strings.NewReplacer("{pending}", strconv.Itoa(pending)).Replace(format)
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPromptQuietWithSkippedDuplicates(t *testing.T) {
+	useTestDir(t)
+	config.duplicates = duplicatesSkip
+
+	now := time.Now()
+	writeTodoFile(t, "5", "\x00old five", now.Add(-time.Hour))
+	writeTodoFile(t, "05", "\x00new five in 05", now)
+	writeTodoFile(t, "6", "\x00six", now)
+	writeTodoFile(t, "7", "\x01seven", now)
+
+	var code int
+	stdout, stderr := captureOutput(t, func() { code = promptCommand() })
+
+	// 5 is skipped like listings skip it, 7 is completed
+	if code != 0 || stdout != promptSegment(stderrTerminal, 1) {
+		t.Errorf("prompt = %d %q, want 0 %q", code, stdout, promptSegment(stderrTerminal, 1))
+	}
+	if stderr != "" {
+		t.Errorf("prompt wrote %q to stderr, it would show up at every prompt", stderr)
+	}
+}
+
+func TestPromptNothingWithoutPending(t *testing.T) {
+	useTestDir(t)
+	writeTodoFile(t, "7", "\x01seven", time.Now())
+
+	stdout, stderr := captureOutput(t, func() { promptCommand() })
+	if stdout != "" || stderr != "" {
+		t.Errorf("prompt with only completed todos printed %q and %q, want nothing", stdout, stderr)
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// With rank_ids on todos are shown as #1, #2, ... instead of the random ids. The
+// number is stored with the todo when it's created, so adding or deleting other
+// todos never changes which todo #3 is. Numbers of deleted todos leave gaps: the
+// next number is kept in its own file, a deleted #4 isn't in any todo file any more
+
+// rankFile holds the number the next todo gets
+func rankFile() string {
+	return path.Join(getDirPath(), "next-rank")
+}
+
+// nextRank hands out the next number and moves the mark past it, it only reads
+// and writes the rank file, so giving numbers to many todos in a row stays cheap
+func nextRank() (int, error) {
+	next := storedNextRank()
+	if err := writeNextRank(next + 1); err != nil {
+		return 0, err
+	}
+
+	return next, nil
+}
+
+// reserveRank moves the mark past a number that didn't come from nextRank, like
+// one restored from a snapshot, so it isn't handed out again
+func reserveRank(rank int) error {
+	if rank < storedNextRank() {
+		return nil
+	}
+
+	return writeNextRank(rank + 1)
+}
+
+// storedNextRank reads the mark. Directories from before it was kept, or where it got
+// lost, continue after the highest number stored
+func storedNextRank() int {
+	data, err := os.ReadFile(rankFile())
+	if err == nil {
+		if next, err := strconv.Atoi(strings.TrimSpace(string(data))); err == nil && next > 0 {
+			return next
+		}
+	}
+
+	highest := 0
+	for _, file := range scanTodoFiles() {
+		todo, err := readTodoState(file.path, file.id)
+		if err != nil {
+			continue
+		}
+		highest = max(highest, todo.rank)
+	}
+
+	return highest + 1
+}
+
+func writeNextRank(next int) error {
+	return writeFileAtomic(rankFile(), []byte(strconv.Itoa(next)+"\n"))
+}
+
+// idForRank finds the todo numbered rank, reading only the file headers
+func idForRank(rank int) (TODOId, bool) {
+	if rank < 1 {
+		return 0, false
+	}
+
+	for _, file := range listTodoFiles() {
+		todo, err := readTodoState(file.path, file.id)
+		if err == nil && todo.rank == rank {
+			return todo.id, true
+		}
+	}
+
+	return 0, false
+}
+
+// rankLabel is how the todo is shown to select it: #N with rank_ids on, the id
+// otherwise and for todos from before numbers were stored
+func rankLabel(todo *Todo) string {
+	if config.rankIds && todo.rank > 0 {
+		return fmt.Sprintf("#%d", todo.rank)
+	}
+
+	return strconv.Itoa(int(todo.id))
+}
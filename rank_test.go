@@ -0,0 +1,140 @@
+package main
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func mustNextRank(t *testing.T) int {
+	t.Helper()
+
+	rank, err := nextRank()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rank
+}
+
+func TestRanksStayAfterAddAndDelete(t *testing.T) {
+	useTestDir(t)
+
+	// ids in creation order are high, low, middle, so id order isn't creation order
+	ids := []TODOId{900, 5, 400}
+	for _, id := range ids {
+		todo := &Todo{id: id, rank: mustNextRank(t), title: "todo"}
+		if err := todo.save(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	check := func(rank int, want TODOId) {
+		t.Helper()
+		id, found := idForRank(rank)
+		if !found || id != want {
+			t.Errorf("idForRank(%d) = %d, %t, want %d", rank, id, found, want)
+		}
+	}
+	check(1, 900)
+	check(2, 5)
+	check(3, 400)
+
+	todo, err := LoadTodo(5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	todo.delete()
+
+	check(1, 900)
+	check(3, 400)
+	if id, found := idForRank(2); found {
+		t.Errorf("#2 is todo %d after deleting it", id)
+	}
+
+	// a new todo with a lower id than all of them still goes last
+	added := &Todo{id: 1, rank: mustNextRank(t), title: "new"}
+	if err := added.save(); err != nil {
+		t.Fatal(err)
+	}
+	check(1, 900)
+	check(3, 400)
+	check(4, 1)
+
+	// deleting the highest number doesn't free it either
+	(&Todo{id: 1}).delete()
+	if id, found := idForRank(4); found {
+		t.Errorf("#4 is todo %d after deleting it", id)
+	}
+	again, err := addTodo("after deleting #4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.rank != 5 {
+		t.Errorf("todo added after deleting #4 got #%d, want #5", again.rank)
+	}
+	check(5, again.id)
+}
+
+func TestNextRankContinuesOlderDirectories(t *testing.T) {
+	useTestDir(t)
+
+	// saved before the next number was kept in its own file
+	writeTodoFile(t, "1", "\x02rank=3\n\nbuy milk", time.Now())
+	writeTodoFile(t, "2", "\x02rank=7\n\nwalk dog", time.Now())
+	writeTodoFile(t, "3", "completed: false\nolder", time.Now())
+	if rank := mustNextRank(t); rank != 8 {
+		t.Errorf("nextRank() = %d, want 8 after the highest stored number", rank)
+	}
+	if rank := mustNextRank(t); rank != 9 {
+		t.Errorf("second nextRank() = %d, want 9", rank)
+	}
+}
+
+func TestRankFromSnapshotIsReserved(t *testing.T) {
+	useTestDir(t)
+
+	// restored from a snapshot, with a number nextRank never gave out here
+	restored := &Todo{id: 5, uuid: "8f14e45f-ceea-4e6b-9b1a-3c2d5a6e7f80", rank: 12, title: "restored"}
+	if err := restored.save(); err != nil {
+		t.Fatal(err)
+	}
+
+	if rank := mustNextRank(t); rank != 13 {
+		t.Errorf("nextRank() after restoring #12 = %d, want 13", rank)
+	}
+}
+
+func TestRankFileInvalid(t *testing.T) {
+	useTestDir(t)
+	writeTodoFile(t, "5", "\x02rank=2\n\nbuy milk", time.Now())
+
+	if err := os.WriteFile(rankFile(), []byte("lots\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	if rank := mustNextRank(t); rank != 3 {
+		t.Errorf("nextRank() with a broken rank file = %d, want 3", rank)
+	}
+}
+
+func TestRankGivenToOlderTodosOnSave(t *testing.T) {
+	useTestDir(t)
+
+	todo, err := addTodo("new")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// saved by an older version, without a rank
+	older := &Todo{id: todo.id + 1, title: "older"}
+	if err := older.save(); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadTodo(older.id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.rank != todo.rank+1 {
+		t.Errorf("older todo got rank %d, want %d", loaded.rank, todo.rank+1)
+	}
+}
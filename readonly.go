@@ -0,0 +1,49 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+var readOnlyFlag = flag.Bool("read-only", false, "refuse anything that changes todos, listing still works")
+
+var ErrReadOnly = errors.New("read-only mode")
+
+// changingCommands are the commands refused in read-only mode, stale only changes
+// todos with --delete so it checks for itself
+var changingCommands = map[string]bool{
+	"add":            true,
+	"complete-all":   true,
+	"migrate":        true,
+	"normalize":      true,
+	"apply-snapshot": true,
+}
+
+func isReadOnly() bool {
+	return *readOnlyFlag || config.readOnly
+}
+
+// refuseInReadOnly prints why the command can't run and tells the caller to stop
+func refuseInReadOnly(what string) bool {
+	if !isReadOnly() {
+		return false
+	}
+
+	fmt.Fprintf(os.Stderr, "Can't %s: %v\n", what, ErrReadOnly)
+	return true
+}
+
+// refuseWrites lets only GET requests through in read-only mode
+func refuseWrites(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if isReadOnly() && r.Method != http.MethodGet {
+			writeError(w, http.StatusMethodNotAllowed, ErrReadOnly)
+			return
+		}
+
+		handler(w, r)
+	}
+}
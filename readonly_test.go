@@ -0,0 +1,133 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func useReadOnly(t *testing.T) string {
+	t.Helper()
+
+	dir := useTestDir(t)
+	config.readOnly = true
+	return dir
+}
+
+func TestRefuseWrites(t *testing.T) {
+	useReadOnly(t)
+
+	methods := map[string]int{
+		http.MethodGet:    http.StatusOK,
+		http.MethodPost:   http.StatusMethodNotAllowed,
+		http.MethodPut:    http.StatusMethodNotAllowed,
+		http.MethodDelete: http.StatusMethodNotAllowed,
+		http.MethodPatch:  http.StatusMethodNotAllowed,
+	}
+
+	for method, want := range methods {
+		called := false
+		handler := refuseWrites(func(w http.ResponseWriter, r *http.Request) {
+			called = true
+			w.WriteHeader(http.StatusOK)
+		})
+
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest(method, "/todos", nil))
+
+		if w.Code != want {
+			t.Errorf("%s in read-only mode = %d, want %d", method, w.Code, want)
+		}
+		if called != (want == http.StatusOK) {
+			t.Errorf("%s in read-only mode reached the handler: %t", method, called)
+		}
+		if want != http.StatusOK && !strings.Contains(w.Body.String(), ErrReadOnly.Error()) {
+			t.Errorf("%s in read-only mode answered %q, want the read-only error", method, w.Body.String())
+		}
+	}
+}
+
+func TestRefuseWritesOffByDefault(t *testing.T) {
+	useTestDir(t)
+
+	called := false
+	handler := refuseWrites(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	})
+	handler(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/todos", nil))
+
+	if !called {
+		t.Error("POST didn't reach the handler without read-only mode")
+	}
+}
+
+func TestRunCommandRefusesChangingCommands(t *testing.T) {
+	dir := useReadOnly(t)
+	// something for migrate and normalize to change if they ran
+	writeTodoFile(t, "05", "\x00 buy milk ", time.Now())
+
+	for name := range changingCommands {
+		var code int
+		_, stderr := captureOutput(t, func() {
+			code = runCommand([]string{name, "buy milk"})
+		})
+
+		if code != 1 {
+			t.Errorf("%s in read-only mode exited with %d, want 1", name, code)
+		}
+		if !strings.Contains(stderr, ErrReadOnly.Error()) {
+			t.Errorf("%s in read-only mode printed %q, want the read-only error", name, stderr)
+		}
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 || entries[0].Name() != "05" {
+		t.Errorf("files after refused commands: %v, want only 05 untouched", entries)
+	}
+	if data, _ := os.ReadFile(dir + "/05"); string(data) != "\x00 buy milk " {
+		t.Errorf("05 changed to %q by refused commands", data)
+	}
+}
+
+func TestStaleDeleteRefused(t *testing.T) {
+	dir := useReadOnly(t)
+	writeTodoFile(t, "5", "\x01buy milk", time.Now().AddDate(0, 0, -30))
+
+	var code int
+	captureOutput(t, func() {
+		code = runCommand([]string{"stale", "--delete"})
+	})
+
+	if code != 1 {
+		t.Errorf("stale --delete in read-only mode exited with %d, want 1", code)
+	}
+	if _, err := os.Stat(dir + "/5"); err != nil {
+		t.Errorf("stale --delete in read-only mode deleted the todo: %v", err)
+	}
+}
+
+func TestIsChangingAction(t *testing.T) {
+	// the actions that save, delete or write templates, kept apart from menuActions so
+	// a new action marked wrong there shows up here
+	changing := map[int]bool{2: true, 3: true, 4: true, 5: true, 8: true, 10: true, 11: true, 13: true, 15: true, 16: true}
+
+	for _, action := range menuActions {
+		if got := isChangingAction(action.number); got != changing[action.number] {
+			t.Errorf("isChangingAction(%d) for %q = %t, want %t", action.number, action.label, got, changing[action.number])
+		}
+		delete(changing, action.number)
+	}
+	for number := range changing {
+		t.Errorf("changing action %d isn't in the menu any more, update the test", number)
+	}
+
+	// numbers that aren't actions go to the "unknown action" message in any mode
+	for _, number := range []int{0, -1, len(menuActions) + 100} {
+		if isChangingAction(number) {
+			t.Errorf("isChangingAction(%d) = true for no action", number)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// serveCommand exposes the todos over a small REST API, all bodies are TodoDTO JSON:
+//
+//	GET    /todos
+//	POST   /todos
+//	GET    /todos/{id}
+//	PUT    /todos/{id}
+//	DELETE /todos/{id}
+//	POST   /todos/{id}/complete
+func serveCommand(args []string) int {
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	addr := flags.String("addr", ":8080", "address to listen on")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if !isReadOnly() {
+		requireWritable()
+	}
+
+	log.Printf("Serving todos from %s on %s", getDirPath(), *addr)
+	if err := http.ListenAndServe(*addr, newServeMux()); err != nil {
+		fmt.Fprintf(os.Stderr, "Error serving: %+v\n", err)
+		return 1
+	}
+
+	return 0
+}
+
+func newServeMux() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/todos", locked(refuseWrites(handleTodos)))
+	mux.HandleFunc("/todos/", locked(refuseWrites(handleTodo)))
+	return mux
+}
+
+// locked runs one request at a time, so concurrent requests don't interleave
+// their reads and writes of the todo files
+func locked(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		store.mu.Lock()
+		defer store.mu.Unlock()
+
+		handler(w, r)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+// checkDTO rejects what clients may not store: titles breaking the title rules and
+// attachments that attachFile would refuse
+func checkDTO(dto TodoDTO) error {
+	if err := validateTitle(dto.Title); err != nil {
+		return err
+	}
+	for _, attachment := range dto.Attachments {
+		if err := checkAttachment(attachment); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func handleTodos(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		todos := make([]TodoDTO, 0)
+		for _, todo := range store.All(nil) {
+			todos = append(todos, toDTO(todo))
+		}
+		writeJSON(w, http.StatusOK, todos)
+	case http.MethodPost:
+		dto := TodoDTO{}
+		if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		if err := checkDTO(dto); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		if !checkTodoLimit() {
+			writeError(w, http.StatusConflict, errors.New("too many todos"))
+			return
+		}
+
+		todo, err := addTodo(dto.Title)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		if dto.Completed || len(dto.Attachments) > 0 {
+			todo.completed = dto.Completed
+			todo.attachments = dto.Attachments
+			if err := todo.save(); err != nil {
+				writeError(w, http.StatusInternalServerError, err)
+				return
+			}
+		}
+
+		writeJSON(w, http.StatusCreated, toDTO(todo))
+	default:
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("%s not allowed", r.Method))
+	}
+}
+
+func handleTodo(w http.ResponseWriter, r *http.Request) {
+	rest := strings.TrimPrefix(r.URL.Path, "/todos/")
+	idRaw, action, _ := strings.Cut(rest, "/")
+
+	idInt, err := strconv.ParseUint(idRaw, 10, strconv.IntSize)
+	if err != nil {
+		writeError(w, http.StatusNotFound, ErrTodoNotFound)
+		return
+	}
+	id := TODOId(idInt)
+
+	var todo *Todo
+
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		todo, err = LoadTodo(id)
+	case action == "" && r.Method == http.MethodPut:
+		dto := TodoDTO{}
+		if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		if err := checkDTO(dto); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		err = store.Update(id, func(updated *Todo) error {
+			updated.update(dto.Title)
+			updated.completed = dto.Completed
+			updated.attachments = dto.Attachments
+			todo = updated
+			return nil
+		})
+		if err == nil {
+			emitEvent("edit", todo)
+		}
+	case action == "" && r.Method == http.MethodDelete:
+		todo, err = LoadTodo(id)
+		if err == nil {
+			todo.delete()
+			emitEvent("delete", todo)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	case action == "complete" && r.Method == http.MethodPost:
+		err = store.Update(id, func(updated *Todo) error {
+			updated.complete()
+			todo = updated
+			return nil
+		})
+		if err == nil {
+			emitEvent("complete", todo)
+		}
+	default:
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("%s %s not allowed", r.Method, r.URL.Path))
+		return
+	}
+
+	if errors.Is(err, ErrTodoNotFound) {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, toDTO(todo))
+}
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// request sends one request to the API and returns the status and the body
+func request(t *testing.T, method, target, body string) (int, string) {
+	t.Helper()
+
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	w := httptest.NewRecorder()
+	newServeMux().ServeHTTP(w, r)
+
+	return w.Code, w.Body.String()
+}
+
+func todoPath(id TODOId) string {
+	return "/todos/" + strconv.FormatUint(uint64(id), 10)
+}
+
+func decodeDTO(t *testing.T, body string) TodoDTO {
+	t.Helper()
+
+	dto := TodoDTO{}
+	if err := json.Unmarshal([]byte(body), &dto); err != nil {
+		t.Fatalf("decoding %q: %v", body, err)
+	}
+	return dto
+}
+
+// addTestTodo adds a todo through the API and returns it as the response had it
+func addTestTodo(t *testing.T, body string) TodoDTO {
+	t.Helper()
+
+	status, response := request(t, http.MethodPost, "/todos", body)
+	if status != http.StatusCreated {
+		t.Fatalf("POST /todos %s = %d %s, want 201", body, status, response)
+	}
+	return decodeDTO(t, response)
+}
+
+func TestServeList(t *testing.T) {
+	useTestDir(t)
+
+	status, body := request(t, http.MethodGet, "/todos", "")
+	if status != http.StatusOK || strings.TrimSpace(body) != "[]" {
+		t.Errorf("GET /todos with no todos = %d %s, want 200 []", status, body)
+	}
+
+	addTestTodo(t, `{"title":"buy milk"}`)
+	addTestTodo(t, `{"title":"walk dog","completed":true}`)
+
+	status, body = request(t, http.MethodGet, "/todos", "")
+	todos := []TodoDTO{}
+	if err := json.Unmarshal([]byte(body), &todos); err != nil {
+		t.Fatal(err)
+	}
+	// ids are random, so the order isn't known
+	completed := map[string]bool{}
+	for _, todo := range todos {
+		completed[todo.Title] = todo.Completed
+	}
+	if status != http.StatusOK || len(todos) != 2 || completed["buy milk"] || !completed["walk dog"] {
+		t.Errorf("GET /todos = %d %s, want both todos", status, body)
+	}
+}
+
+func TestServeAdd(t *testing.T) {
+	useTestDir(t)
+
+	attachment := path.Join(t.TempDir(), "shopping.txt")
+	if err := os.WriteFile(attachment, nil, 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	body, _ := json.Marshal(TodoDTO{Title: "buy milk", Completed: true, Attachments: []string{attachment}})
+	dto := addTestTodo(t, string(body))
+
+	if dto.Id == 0 || dto.UUID == "" || dto.Title != "buy milk" || !dto.Completed ||
+		!slices.Equal(dto.Attachments, []string{attachment}) {
+		t.Errorf("POST /todos returned %+v", dto)
+	}
+
+	todo, err := LoadTodo(dto.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if todo.title != "buy milk" || !todo.completed || !slices.Equal(todo.attachments, []string{attachment}) {
+		t.Errorf("saved %+v, want what was posted", todo)
+	}
+	if files := scanTodoFiles(); len(files) != 1 {
+		t.Errorf("%d todo files after one POST, want 1", len(files))
+	}
+}
+
+func TestServeRejectsBadTodos(t *testing.T) {
+	useTestDir(t)
+	config.titleMaxLength = 10
+
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"not json", `{"title":`},
+		{"wrong type", `{"title":5}`},
+		{"empty title", `{"title":"  "}`},
+		{"title rule", `{"title":"a title that is too long"}`},
+		{"missing attachment", `{"title":"inj","attachments":["/does/not/exist"]}`},
+		{"attachment ending the title", `{"title":"inj","attachments":["/nope\n\nhijacked title"]}`},
+		{"attachment with a header key", `{"title":"inj","attachments":["a\ncompleted=true"]}`},
+		{"attachment with a carriage return", `{"title":"inj","attachments":["/tmp\r"]}`},
+	}
+
+	existing := addTestTodo(t, `{"title":"buy milk"}`)
+
+	for _, test := range tests {
+		if status, body := request(t, http.MethodPost, "/todos", test.body); status != http.StatusBadRequest {
+			t.Errorf("POST /todos with %s = %d %s, want 400", test.name, status, body)
+		}
+
+		status, body := request(t, http.MethodPut, todoPath(existing.Id), test.body)
+		if status != http.StatusBadRequest {
+			t.Errorf("PUT /todos/%d with %s = %d %s, want 400", existing.Id, test.name, status, body)
+		}
+	}
+
+	if files := scanTodoFiles(); len(files) != 1 {
+		t.Errorf("%d todo files after rejected requests, want only the first todo", len(files))
+	}
+	todo, err := LoadTodo(existing.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if todo.title != "buy milk" || todo.completed || len(todo.attachments) > 0 {
+		t.Errorf("todo after rejected PUTs = %+v, want it unchanged", todo)
+	}
+}
+
+func TestServeGet(t *testing.T) {
+	useTestDir(t)
+	added := addTestTodo(t, `{"title":"buy milk"}`)
+
+	status, body := request(t, http.MethodGet, todoPath(added.Id), "")
+	if got := decodeDTO(t, body); status != http.StatusOK || got.Id != added.Id || got.UUID != added.UUID || got.Title != "buy milk" {
+		t.Errorf("GET /todos/%d = %d %s, want %+v", added.Id, status, body, added)
+	}
+}
+
+func TestServeUpdate(t *testing.T) {
+	useTestDir(t)
+	added := addTestTodo(t, `{"title":"buy milk"}`)
+
+	status, body := request(t, http.MethodPut, todoPath(added.Id), `{"title":"buy oat milk","completed":true}`)
+	dto := decodeDTO(t, body)
+	if status != http.StatusOK || dto.Title != "buy oat milk" || !dto.Completed || dto.UUID != added.UUID {
+		t.Errorf("PUT /todos/%d = %d %s, want the todo changed with the same uuid", added.Id, status, body)
+	}
+
+	todo, err := LoadTodo(added.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if todo.title != "buy oat milk" || !todo.completed {
+		t.Errorf("saved %+v after PUT", todo)
+	}
+}
+
+func TestServeDelete(t *testing.T) {
+	useTestDir(t)
+	added := addTestTodo(t, `{"title":"buy milk"}`)
+	target := todoPath(added.Id)
+
+	if status, body := request(t, http.MethodDelete, target, ""); status != http.StatusNoContent {
+		t.Errorf("DELETE %s = %d %s, want 204", target, status, body)
+	}
+	if store.Exists(added.Id) {
+		t.Errorf("todo %d still exists after DELETE", added.Id)
+	}
+	if status, _ := request(t, http.MethodGet, target, ""); status != http.StatusNotFound {
+		t.Errorf("GET %s after DELETE = %d, want 404", target, status)
+	}
+}
+
+func TestServeComplete(t *testing.T) {
+	useTestDir(t)
+	added := addTestTodo(t, `{"title":"buy milk"}`)
+	target := todoPath(added.Id) + "/complete"
+
+	status, body := request(t, http.MethodPost, target, "")
+	if status != http.StatusOK || !decodeDTO(t, body).Completed {
+		t.Errorf("POST %s = %d %s, want the completed todo", target, status, body)
+	}
+	if todo, err := LoadTodo(added.Id); err != nil || !todo.completed {
+		t.Errorf("todo after POST %s = %+v, %v, want it completed", target, todo, err)
+	}
+}
+
+func TestServeNotFound(t *testing.T) {
+	useTestDir(t)
+
+	tests := []struct {
+		method, target, body string
+	}{
+		{http.MethodGet, "/todos/5", ""},
+		{http.MethodGet, "/todos/abc", ""},
+		{http.MethodGet, "/todos/-1", ""},
+		{http.MethodPut, "/todos/5", `{"title":"buy milk"}`},
+		{http.MethodDelete, "/todos/5", ""},
+		{http.MethodPost, "/todos/5/complete", ""},
+		{http.MethodGet, "/other", ""},
+	}
+
+	for _, test := range tests {
+		if status, body := request(t, test.method, test.target, test.body); status != http.StatusNotFound {
+			t.Errorf("%s %s = %d %s, want 404", test.method, test.target, status, body)
+		}
+	}
+
+	if entries, _ := os.ReadDir(dataDir); len(entries) > 0 {
+		t.Errorf("%d files created by requests for missing todos", len(entries))
+	}
+}
+
+func TestServeMethodNotAllowed(t *testing.T) {
+	useTestDir(t)
+	added := addTestTodo(t, `{"title":"buy milk"}`)
+	target := todoPath(added.Id)
+
+	tests := []struct {
+		method, target string
+	}{
+		{http.MethodPut, "/todos"},
+		{http.MethodDelete, "/todos"},
+		{http.MethodPatch, "/todos"},
+		{http.MethodPost, target},
+		{http.MethodPatch, target},
+		{http.MethodGet, target + "/complete"},
+		{http.MethodPost, target + "/archive"},
+	}
+
+	for _, test := range tests {
+		if status, body := request(t, test.method, test.target, ""); status != http.StatusMethodNotAllowed {
+			t.Errorf("%s %s = %d %s, want 405", test.method, test.target, status, body)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Short codes are the id in Crockford's base32 with a check symbol, prefixed with T,
+// e.g. id 538 is TGTM. They're meant for todos printed on paper: easy to read
+// and type back, and the check symbol catches most typos
+const (
+	shortcodePrefix   = "T"
+	crockfordAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+	crockfordCheck    = crockfordAlphabet + "*~$=U"
+)
+
+func shortcode(id TODOId) string {
+	digits := ""
+	for n := uint64(id); ; n /= 32 {
+		digits = string(crockfordAlphabet[n%32]) + digits
+		if n < 32 {
+			break
+		}
+	}
+
+	return shortcodePrefix + digits + string(crockfordCheck[uint64(id)%37])
+}
+
+// parseShortcode accepts codes in any case, and like Crockford's base32
+// reads I and L as 1 and O as 0
+func parseShortcode(code string) (TODOId, bool) {
+	code = strings.ToUpper(code)
+	code = strings.NewReplacer("I", "1", "L", "1", "O", "0").Replace(code)
+
+	digits, found := strings.CutPrefix(code, shortcodePrefix)
+	if !found || len(digits) < 2 {
+		return 0, false
+	}
+	check := digits[len(digits)-1]
+	digits = digits[:len(digits)-1]
+
+	var n uint64
+	for _, r := range digits {
+		value := strings.IndexRune(crockfordAlphabet, r)
+		if value < 0 || n > (^uint64(0))/32 {
+			return 0, false
+		}
+		n = n*32 + uint64(value)
+	}
+
+	id := TODOId(n)
+	if uint64(id) != n || crockfordCheck[n%37] != check {
+		return 0, false
+	}
+
+	return id, true
+}
+
+func printShortcodes() {
+	todos := store.All(isPending)
+	fmt.Println(accent(fmt.Sprintf("%d uncompleted todos:", len(todos))))
+
+	for _, todo := range todos {
+		fmt.Printf("%s\t%s\n", shortcode(todo.id), todo.title)
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestShortcodeRoundTrip(t *testing.T) {
+	ids := []TODOId{0, 1, 31, 32, 36, 37, 538, 999, 1 << 20, 1<<32 + 7, ^TODOId(0)}
+
+	for _, id := range ids {
+		code := shortcode(id)
+		parsed, ok := parseShortcode(code)
+		if !ok || parsed != id {
+			t.Errorf("parseShortcode(shortcode(%d) = %q) = %d, %t", id, code, parsed, ok)
+		}
+	}
+
+	if code := shortcode(538); code != "TGTM" {
+		t.Errorf("shortcode(538) = %q, want TGTM as in the readme", code)
+	}
+}
+
+func TestShortcodeWrongCheckSymbol(t *testing.T) {
+	for _, id := range []TODOId{0, 538, 999, ^TODOId(0)} {
+		code := shortcode(id)
+		body, check := code[:len(code)-1], code[len(code)-1]
+
+		for i := 0; i < len(crockfordCheck); i++ {
+			if crockfordCheck[i] == check {
+				continue
+			}
+			wrong := body + string(crockfordCheck[i])
+			if parsed, ok := parseShortcode(wrong); ok {
+				t.Errorf("parseShortcode(%q) = %d with a wrong check symbol, want it rejected", wrong, parsed)
+			}
+		}
+	}
+
+	// a typo in a digit is caught too
+	if parsed, ok := parseShortcode("TGVM"); ok {
+		t.Errorf("parseShortcode(TGVM) = %d, want the typo caught", parsed)
+	}
+}
+
+func TestShortcodeCaseAndAliases(t *testing.T) {
+	// 1057 is 111 in base32, 1024 is 100
+	tests := []struct {
+		code string
+		want TODOId
+	}{
+		{"tgtm", 538},
+		{"TgTm", 538},
+		{strings.ToLower(shortcode(1057)), 1057},
+		{strings.ReplaceAll(shortcode(1057), "1", "I"), 1057},
+		{strings.ReplaceAll(shortcode(1057), "1", "l"), 1057},
+		{strings.ReplaceAll(shortcode(1057), "1", "L"), 1057},
+		{strings.ReplaceAll(shortcode(1024), "0", "O"), 1024},
+		{strings.ReplaceAll(shortcode(1024), "0", "o"), 1024},
+		{"T00" + shortcode(538)[1:], 538}, // leading zeros don't change the id
+	}
+
+	for _, test := range tests {
+		parsed, ok := parseShortcode(test.code)
+		if !ok || parsed != test.want {
+			t.Errorf("parseShortcode(%q) = %d, %t, want %d", test.code, parsed, ok, test.want)
+		}
+	}
+}
+
+func TestShortcodeRejected(t *testing.T) {
+	largest := shortcode(^TODOId(0))
+
+	codes := []string{
+		"",
+		"T",
+		"T0",  // only a check symbol
+		"GTM", // no prefix
+		"XGTM",
+		"TG-M",
+		"TGUM", // U is only a check symbol
+		"TG*M",
+		"538",
+		// one digit more than the largest id
+		"T1" + largest[1:],
+		"T" + strings.Repeat("Z", 20) + "0",
+	}
+
+	for _, code := range codes {
+		if parsed, ok := parseShortcode(code); ok {
+			t.Errorf("parseShortcode(%q) = %d, want it rejected", code, parsed)
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+const snapshotVersion = 1
+
+// Snapshot is the whole store in one JSON file, meant as a full backup
+type Snapshot struct {
+	Version int       `json:"version"`
+	Created time.Time `json:"created"`
+	Todos   []TodoDTO `json:"todos"`
+}
+
+func takeSnapshot() Snapshot {
+	snapshot := Snapshot{
+		Version: snapshotVersion,
+		Created: time.Now().UTC(),
+		Todos:   make([]TodoDTO, 0),
+	}
+
+	// always in id order so snapshots of the same state are identical
+	todos := store.All(nil)
+	slices.SortFunc(todos, sortKeys["id"])
+
+	for _, todo := range todos {
+		snapshot.Todos = append(snapshot.Todos, toDTO(todo))
+	}
+
+	return snapshot
+}
+
+func marshalSnapshot(snapshot Snapshot) ([]byte, error) {
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append(data, '\n'), nil
+}
+
+func readSnapshot(filepath string) (*Snapshot, error) {
+	data, err := os.ReadFile(filepath)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := &Snapshot{}
+	if err := json.Unmarshal(data, snapshot); err != nil {
+		return nil, fmt.Errorf("not a snapshot: %w", err)
+	}
+
+	if snapshot.Version != snapshotVersion {
+		return nil, fmt.Errorf("unsupported snapshot version %d", snapshot.Version)
+	}
+
+	seen := make(map[TODOId]bool, len(snapshot.Todos))
+	for _, todo := range snapshot.Todos {
+		if seen[todo.Id] {
+			return nil, fmt.Errorf("todo %d is in the snapshot twice", todo.Id)
+		}
+		seen[todo.Id] = true
+	}
+
+	return snapshot, nil
+}
+
+// snapshotCommand writes the snapshot to the given file, or stdout without one
+func snapshotCommand(args []string) int {
+	data, err := marshalSnapshot(takeSnapshot())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating snapshot: %+v\n", err)
+		return 1
+	}
+
+	if len(args) == 0 {
+		os.Stdout.Write(data)
+		return 0
+	}
+
+	if err := os.WriteFile(args[0], data, 0666); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing snapshot: %+v\n", err)
+		return 1
+	}
+
+	return 0
+}
+
+func applySnapshotCommand(args []string) int {
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, "Usage: apply-snapshot <file>")
+		return 2
+	}
+
+	snapshot, err := readSnapshot(args[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading snapshot %s: %+v\n", args[0], err)
+		return 1
+	}
+
+	existing := 0
+	for _, todo := range snapshot.Todos {
+		if store.Exists(todo.Id) {
+			existing++
+		}
+	}
+
+	if existing > 0 && !askYesNo(fmt.Sprintf("%d todos already exist and will be overwritten. Continue?", existing)) {
+		fmt.Println("Nothing restored")
+		return 1
+	}
+
+	failed := 0
+	for _, todo := range snapshot.Todos {
+		if err := fromDTO(todo).save(); err != nil {
+			fmt.Printf("%d: %v\n", todo.Id, err)
+			failed++
+		}
+	}
+
+	fmt.Printf("%d todos restored\n", len(snapshot.Todos)-failed)
+
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+type snapshotChange struct {
+	before, after TodoDTO
+}
+
+type snapshotDiff struct {
+	added   []TodoDTO
+	removed []TodoDTO
+	changed []snapshotChange
+}
+
+// diffSnapshots matches todos by uuid, or by id when one side has no uuid (snapshots
+// taken before uuids existed). Added and removed are from the point of view of after
+func diffSnapshots(before, after Snapshot) snapshotDiff {
+	diff := snapshotDiff{}
+
+	afterById := make(map[TODOId]int, len(after.Todos))
+	afterByUUID := make(map[string]int, len(after.Todos))
+	for i, todo := range after.Todos {
+		afterById[todo.Id] = i
+		if todo.UUID != "" {
+			afterByUUID[todo.UUID] = i
+		}
+	}
+
+	matched := make(map[int]bool, len(before.Todos))
+	for _, old := range before.Todos {
+		i, found := afterByUUID[old.UUID]
+		if old.UUID == "" || !found {
+			i, found = afterById[old.Id]
+			// same id but another uuid is another todo
+			if found && old.UUID != "" && after.Todos[i].UUID != "" {
+				found = false
+			}
+		}
+		if !found || matched[i] {
+			diff.removed = append(diff.removed, old)
+			continue
+		}
+		matched[i] = true
+
+		current := after.Todos[i]
+		if old.Id != current.Id || old.Title != current.Title || old.Completed != current.Completed || !slices.Equal(old.Attachments, current.Attachments) {
+			diff.changed = append(diff.changed, snapshotChange{before: old, after: current})
+		}
+	}
+
+	for i, todo := range after.Todos {
+		if !matched[i] {
+			diff.added = append(diff.added, todo)
+		}
+	}
+
+	return diff
+}
+
+func (change snapshotChange) describe() string {
+	parts := make([]string, 0, 4)
+
+	if change.before.Id != change.after.Id {
+		parts = append(parts, fmt.Sprintf("id %d -> %d", change.before.Id, change.after.Id))
+	}
+	if change.before.Title != change.after.Title {
+		parts = append(parts, fmt.Sprintf("title %q -> %q", change.before.Title, change.after.Title))
+	}
+	if change.before.Completed != change.after.Completed {
+		parts = append(parts, fmt.Sprintf("completed %t -> %t", change.before.Completed, change.after.Completed))
+	}
+	if !slices.Equal(change.before.Attachments, change.after.Attachments) {
+		parts = append(parts, fmt.Sprintf("attachments %d -> %d", len(change.before.Attachments), len(change.after.Attachments)))
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// diffSnapshotCommand shows how the live todos drifted from a snapshot, it never writes anything
+func diffSnapshotCommand(args []string) int {
+	flags := flag.NewFlagSet("diff-snapshot", flag.ContinueOnError)
+	detail := flags.Bool("detail", false, "list every added, removed and changed todo")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if flags.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "Usage: diff-snapshot [--detail] <file>")
+		return 2
+	}
+
+	snapshot, err := readSnapshot(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading snapshot %s: %+v\n", flags.Arg(0), err)
+		return 1
+	}
+
+	diff := diffSnapshots(*snapshot, takeSnapshot())
+
+	fmt.Printf("Since snapshot of %s: %d added, %d removed, %d changed\n",
+		snapshot.Created.Local().Format(time.DateTime), len(diff.added), len(diff.removed), len(diff.changed))
+
+	if !*detail {
+		return 0
+	}
+
+	for _, todo := range diff.added {
+		fmt.Printf("+ %d\t%s\n", todo.Id, todo.Title)
+	}
+	for _, todo := range diff.removed {
+		fmt.Printf("- %d\t%s\n", todo.Id, todo.Title)
+	}
+	for _, change := range diff.changed {
+		fmt.Printf("~ %d\t%s\n", change.after.Id, change.describe())
+	}
+
+	return 0
+}
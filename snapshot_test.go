@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+const (
+	uuidMilk = "8f14e45f-ceea-4e6b-9b1a-3c2d5a6e7f80"
+	uuidDog  = "0b7e9a1c-2f4d-4e8a-9c3b-5d6e7f8a9b0c"
+	uuidMom  = "5d41402a-bc4b-4a76-b971-9d911017c592"
+)
+
+// diffSummary lists the ids of a diff, changes as "before->after"
+func diffSummary(diff snapshotDiff) (added, removed, changed []string) {
+	added, removed, changed = []string{}, []string{}, []string{}
+	for _, todo := range diff.added {
+		added = append(added, fmt.Sprint(todo.Id))
+	}
+	for _, todo := range diff.removed {
+		removed = append(removed, fmt.Sprint(todo.Id))
+	}
+	for _, change := range diff.changed {
+		changed = append(changed, fmt.Sprintf("%d->%d", change.before.Id, change.after.Id))
+	}
+	return added, removed, changed
+}
+
+func TestDiffSnapshots(t *testing.T) {
+	milk := TodoDTO{Id: 5, UUID: uuidMilk, Title: "buy milk"}
+	dog := TodoDTO{Id: 12, UUID: uuidDog, Title: "walk dog"}
+
+	tests := []struct {
+		name                    string
+		before, after           []TodoDTO
+		added, removed, changed []string
+	}{
+		{
+			name:   "unchanged",
+			before: []TodoDTO{milk, dog},
+			after:  []TodoDTO{dog, milk},
+		},
+		{
+			name:   "added",
+			before: []TodoDTO{milk},
+			after:  []TodoDTO{milk, dog},
+			added:  []string{"12"},
+		},
+		{
+			name:    "removed",
+			before:  []TodoDTO{milk, dog},
+			after:   []TodoDTO{milk},
+			removed: []string{"12"},
+		},
+		{
+			name:    "title changed",
+			before:  []TodoDTO{milk},
+			after:   []TodoDTO{{Id: 5, UUID: uuidMilk, Title: "buy oat milk"}},
+			changed: []string{"5->5"},
+		},
+		{
+			name:    "completed",
+			before:  []TodoDTO{milk},
+			after:   []TodoDTO{{Id: 5, UUID: uuidMilk, Title: "buy milk", Completed: true}},
+			changed: []string{"5->5"},
+		},
+		{
+			name:    "attachment added",
+			before:  []TodoDTO{milk},
+			after:   []TodoDTO{{Id: 5, UUID: uuidMilk, Title: "buy milk", Attachments: []string{"/home/me/list.txt"}}},
+			changed: []string{"5->5"},
+		},
+		{
+			name:    "renumbered, same uuid under a new id",
+			before:  []TodoDTO{milk},
+			after:   []TodoDTO{{Id: 40, UUID: uuidMilk, Title: "buy milk"}},
+			changed: []string{"5->40"},
+		},
+		{
+			name:    "same id, another uuid is another todo",
+			before:  []TodoDTO{milk},
+			after:   []TodoDTO{{Id: 5, UUID: uuidMom, Title: "call mom"}},
+			added:   []string{"5"},
+			removed: []string{"5"},
+		},
+		{
+			name:   "pre-uuid snapshot matched by id",
+			before: []TodoDTO{{Id: 5, Title: "buy milk"}, {Id: 12, Title: "walk dog"}},
+			after:  []TodoDTO{milk, {Id: 12, UUID: uuidDog, Title: "walk the dog"}},
+			// getting a uuid isn't a change
+			changed: []string{"12->12"},
+		},
+		{
+			name:    "pre-uuid snapshot, removed and added",
+			before:  []TodoDTO{{Id: 5, Title: "buy milk"}},
+			after:   []TodoDTO{dog},
+			added:   []string{"12"},
+			removed: []string{"5"},
+		},
+		{
+			name:   "pre-uuid live todos",
+			before: []TodoDTO{milk},
+			after:  []TodoDTO{{Id: 5, Title: "buy milk"}, {Id: 6, Title: "new"}},
+			added:  []string{"6"},
+		},
+		{
+			name:   "empty before",
+			before: nil,
+			after:  []TodoDTO{milk, dog},
+			added:  []string{"5", "12"},
+		},
+		{
+			name:    "empty after",
+			before:  []TodoDTO{milk, dog},
+			after:   nil,
+			removed: []string{"5", "12"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			diff := diffSnapshots(Snapshot{Todos: test.before}, Snapshot{Todos: test.after})
+			added, removed, changed := diffSummary(diff)
+
+			for _, got := range []struct {
+				what      string
+				got, want []string
+			}{
+				{"added", added, test.added},
+				{"removed", removed, test.removed},
+				{"changed", changed, test.changed},
+			} {
+				want := got.want
+				if want == nil {
+					want = []string{}
+				}
+				if !slices.Equal(got.got, want) {
+					t.Errorf("%s = %v, want %v", got.what, got.got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestDiffSnapshotsMatchesOnce(t *testing.T) {
+	// both old todos would match the live one, by id and by uuid, only the first does
+	before := []TodoDTO{{Id: 5, Title: "buy milk"}, {Id: 40, UUID: uuidMilk, Title: "buy milk"}}
+	after := []TodoDTO{{Id: 5, UUID: uuidMilk, Title: "buy milk"}}
+
+	added, removed, changed := diffSummary(diffSnapshots(Snapshot{Todos: before}, Snapshot{Todos: after}))
+	if len(added) != 0 || !slices.Equal(removed, []string{"40"}) || len(changed) != 0 {
+		t.Errorf("added %v, removed %v, changed %v, want only 40 removed", added, removed, changed)
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"cmp"
+	"flag"
+	"fmt"
+	"strings"
+)
+
+var sortFlag = flag.String("sort", "id", "comma separated sort keys applied in order: id, title, state")
+
+var reverseFlag = flag.Bool("reverse", false, "reverse the order given by --sort")
+
+// sortKeys compare two todos by one field, new keys only need an entry here
+var sortKeys = map[string]func(a, b *Todo) int{
+	"id": func(a, b *Todo) int {
+		return cmp.Compare(a.id, b.id)
+	},
+	"title": func(a, b *Todo) int {
+		return strings.Compare(strings.ToLower(a.title), strings.ToLower(b.title))
+	},
+	// uncompleted first
+	"state": func(a, b *Todo) int {
+		if a.completed == b.completed {
+			return 0
+		}
+		if a.completed {
+			return 1
+		}
+		return -1
+	},
+}
+
+var todoOrder = sortKeys["id"]
+
+// parseSort turns "state,title" into a comparator that tries each key
+// in order and falls to the next one on a tie
+func parseSort(spec string) (func(a, b *Todo) int, error) {
+	keys := make([]func(a, b *Todo) int, 0)
+
+	for _, name := range strings.Split(spec, ",") {
+		name = strings.TrimSpace(name)
+		key, found := sortKeys[name]
+		if !found {
+			return nil, fmt.Errorf("unknown sort key %q, expected id, title or state", name)
+		}
+		keys = append(keys, key)
+	}
+
+	return func(a, b *Todo) int {
+		for _, key := range keys {
+			if c := key(a, b); c != 0 {
+				return c
+			}
+		}
+		return 0
+	}, nil
+}
+
+// reverse flips the order, todos the comparator calls equal keep their order
+// since listings sort stably
+func reverse(order func(a, b *Todo) int) func(a, b *Todo) int {
+	return func(a, b *Todo) int {
+		return order(b, a)
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+// useSort sets the --sort and --reverse order for the test
+func useSort(t *testing.T, spec string, reversed bool) {
+	t.Helper()
+
+	order, err := parseSort(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reversed {
+		order = reverse(order)
+	}
+
+	saved := todoOrder
+	todoOrder = order
+	t.Cleanup(func() { todoOrder = saved })
+}
+
+func TestSortReverseKeepsTies(t *testing.T) {
+	useTestDir(t)
+
+	todos := []*Todo{
+		{id: 1, title: "buy milk", completed: true},
+		{id: 2, title: "walk dog"},
+		{id: 3, title: "Buy milk"},
+		{id: 4, title: "call mom", completed: true},
+		{id: 5, title: "water plants"},
+	}
+	for _, todo := range todos {
+		if err := todo.save(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		spec     string
+		reversed bool
+		want     []TODOId
+	}{
+		{"id", false, []TODOId{1, 2, 3, 4, 5}},
+		{"id", true, []TODOId{5, 4, 3, 2, 1}},
+		{"state", false, []TODOId{2, 3, 5, 1, 4}},
+		// completed first, but within each state the ids stay in order
+		{"state", true, []TODOId{1, 4, 2, 3, 5}},
+		{"title", false, []TODOId{1, 3, 4, 2, 5}},
+		// "buy milk" and "Buy milk" are a tie, so 1 stays before 3
+		{"title", true, []TODOId{5, 2, 4, 1, 3}},
+		{"state,title", true, []TODOId{4, 1, 5, 2, 3}},
+		{"title,id", true, []TODOId{5, 2, 4, 3, 1}},
+	}
+
+	for _, test := range tests {
+		useSort(t, test.spec, test.reversed)
+
+		got := []TODOId{}
+		for _, todo := range store.All(nil) {
+			got = append(got, todo.id)
+		}
+		if !slices.Equal(got, test.want) {
+			t.Errorf("--sort=%s --reverse=%t gave %v, want %v", test.spec, test.reversed, got, test.want)
+		}
+	}
+}
+
+func TestParseSortUnknownKey(t *testing.T) {
+	if _, err := parseSort("state,due"); err == nil {
+		t.Error("parseSort(state,due) succeeded, want an error for due")
+	}
+}
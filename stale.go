@@ -0,0 +1,88 @@
+package main
+
+import (
+	"cmp"
+	"flag"
+	"fmt"
+	"slices"
+	"time"
+)
+
+type staleTodo struct {
+	todo *Todo
+	age  time.Duration
+}
+
+// staleCommand lists uncompleted todos nobody touched for stale_pending_days and completed
+// ones kept for over stale_completed_days. The todo files only store when a todo was
+// created, not when it was last changed or completed, so the age is taken from the file's
+// modification time, which every save updates. migrate, normalize and apply-snapshot
+// rewrite the files, so right after them every todo looks fresh
+func staleCommand(args []string) int {
+	flags := flag.NewFlagSet("stale", flag.ContinueOnError)
+	deleteCompleted := flags.Bool("delete", false, "offer to delete the stale completed todos")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	modified := map[TODOId]time.Time{}
+	for _, file := range listTodoFiles() {
+		modified[file.id] = file.modified
+	}
+
+	pending := []staleTodo{}
+	completed := []staleTodo{}
+	for _, todo := range store.All(nil) {
+		age := time.Since(modified[todo.id])
+
+		switch {
+		case !todo.completed && age > days(config.stalePendingDays):
+			pending = append(pending, staleTodo{todo, age})
+		case todo.completed && age > days(config.staleCompletedDays):
+			completed = append(completed, staleTodo{todo, age})
+		}
+	}
+
+	fmt.Println(accent(fmt.Sprintf("%d uncompleted todos untouched for over %d days, edit, complete or delete them:",
+		len(pending), config.stalePendingDays)))
+	printStale(pending)
+
+	fmt.Println(accent(fmt.Sprintf("%d completed todos kept for over %d days, delete them:",
+		len(completed), config.staleCompletedDays)))
+	printStale(completed)
+
+	if *deleteCompleted && len(completed) > 0 {
+		if refuseInReadOnly("delete") {
+			return 1
+		}
+		requireWritable()
+		if !askYesNo(fmt.Sprintf("Delete the %d stale completed todos?", len(completed))) {
+			fmt.Println("Nothing deleted")
+			return 1
+		}
+
+		for _, stale := range completed {
+			stale.todo.delete()
+			emitEvent("delete", stale.todo)
+		}
+		fmt.Printf("%d todos deleted\n", len(completed))
+	}
+
+	return 0
+}
+
+func days(n int) time.Duration {
+	return time.Duration(n) * 24 * time.Hour
+}
+
+// printStale lists the oldest first
+func printStale(todos []staleTodo) {
+	slices.SortStableFunc(todos, func(a, b staleTodo) int {
+		return cmp.Compare(b.age, a.age)
+	})
+
+	for _, stale := range todos {
+		fmt.Printf("%4d days  ", int(stale.age/days(1)))
+		stale.todo.print()
+	}
+}
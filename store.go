@@ -0,0 +1,112 @@
+package main
+
+import (
+	"os"
+	"slices"
+	"sync"
+)
+
+type Store struct {
+	// serializes requests in serve mode, the CLI doesn't need it
+	mu sync.Mutex
+}
+
+var store = &Store{}
+
+// All returns the todos matching filter in the --sort order, a nil filter matches every todo
+func (s *Store) All(filter func(*Todo) bool) []*Todo {
+	all := loadAllTodos()
+
+	slices.SortFunc(all, sortKeys["id"])
+
+	todos := make([]*Todo, 0, len(all))
+	for _, todo := range all {
+		if filter == nil || filter(todo) {
+			todos = append(todos, todo)
+		}
+	}
+
+	// stable, so todos equal on every key stay in id order
+	slices.SortStableFunc(todos, todoOrder)
+
+	return todos
+}
+
+// Count is a cheaper len(All(filter)): only the completed state is read from
+// the files, so filter gets todos without their titles. It doesn't warn about
+// skipped duplicates, prompt counts on every shell prompt and would repeat it each time
+func (s *Store) Count(filter func(*Todo) bool) int {
+	count := 0
+
+	for _, file := range pickTodoFiles(false) {
+		todo, err := readTodoState(file.path, file.id)
+
+		if err != nil {
+			continue
+		}
+
+		if filter == nil || filter(todo) {
+			count++
+		}
+	}
+
+	return count
+}
+
+// Exists tells if the id is taken without reading the todo, by a file with leading
+// zeros or even a directory too. Those are rare, so only a missing canonical file
+// costs a scan of the directory
+func (s *Store) Exists(id TODOId) bool {
+	if _, err := os.Stat(getFilePath(id)); err == nil {
+		return true
+	}
+
+	return len(todoFilesFor(id)) > 0
+}
+
+// path is the file the todo is read from and saved to, the one listings show. That's
+// the canonical name for new todos, but can be "05" or the newest of several files
+func (s *Store) path(id TODOId) (string, error) {
+	return pathOf(id, todoFilesFor(id))
+}
+
+// Update loads the todo, lets fn change it and saves the result. Nothing is saved
+// when fn returns an error, which is passed to the caller as is, like a failed save
+func (s *Store) Update(id TODOId, fn func(*Todo) error) error {
+	todo, err := LoadTodo(id)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(todo); err != nil {
+		return err
+	}
+
+	return todo.save()
+}
+
+func isPending(todo *Todo) bool {
+	return !todo.completed
+}
+
+func isCompleted(todo *Todo) bool {
+	return todo.completed
+}
+
+func titleStartsWith(prefix string) func(*Todo) bool {
+	return func(todo *Todo) bool {
+		return hasTitlePrefix(todo, prefix)
+	}
+}
+
+// and combines filters into one matching todos that pass all of them
+func and(filters ...func(*Todo) bool) func(*Todo) bool {
+	return func(todo *Todo) bool {
+		for _, filter := range filters {
+			if !filter(todo) {
+				return false
+			}
+		}
+		return true
+	}
+}
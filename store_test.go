@@ -0,0 +1,264 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestUpdateNotFound(t *testing.T) {
+	dir := useTestDir(t)
+
+	called := false
+	err := store.Update(5, func(todo *Todo) error {
+		called = true
+		return nil
+	})
+
+	if !errors.Is(err, ErrTodoNotFound) {
+		t.Fatalf("Update error = %v, want ErrTodoNotFound", err)
+	}
+	if called {
+		t.Error("fn was called for a missing todo")
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) > 0 {
+		t.Errorf("Update created %d files for a missing todo", len(entries))
+	}
+}
+
+func TestUpdateFnError(t *testing.T) {
+	useTestDir(t)
+
+	todo := &Todo{id: 5, title: "buy milk"}
+	if err := todo.save(); err != nil {
+		t.Fatal(err)
+	}
+	before, err := os.ReadFile(getFilePath(5))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fnErr := errors.New("invalid title")
+	err = store.Update(5, func(todo *Todo) error {
+		todo.update("changed")
+		todo.complete()
+		return fnErr
+	})
+
+	if !errors.Is(err, fnErr) {
+		t.Fatalf("Update error = %v, want the error from fn", err)
+	}
+
+	after, err := os.ReadFile(getFilePath(5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(after) != string(before) {
+		t.Errorf("todo was saved after fn failed: %q, want %q", after, before)
+	}
+}
+
+func TestUpdateSaves(t *testing.T) {
+	useTestDir(t)
+
+	todo := &Todo{id: 5, title: "buy milk"}
+	if err := todo.save(); err != nil {
+		t.Fatal(err)
+	}
+
+	err := store.Update(5, func(todo *Todo) error {
+		todo.complete()
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadTodo(5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !loaded.completed || loaded.title != "buy milk" {
+		t.Errorf("loaded %+v, want the completed todo", loaded)
+	}
+}
+
+func writeTodoFile(t *testing.T, name, data string, modified time.Time) {
+	t.Helper()
+
+	filepath := path.Join(dataDir, name)
+	if err := os.WriteFile(filepath, []byte(data), 0666); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(filepath, modified, modified); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUpdateUsesListedDuplicate(t *testing.T) {
+	useTestDir(t)
+
+	now := time.Now()
+	writeTodoFile(t, "5", "\x00old title", now.Add(-time.Hour))
+	writeTodoFile(t, "05", "\x00new title in 05", now)
+
+	listed := store.All(nil)
+	if len(listed) != 1 || listed[0].title != "new title in 05" {
+		t.Fatalf("listed %+v, want only the newer 05 file", listed)
+	}
+
+	err := store.Update(5, func(todo *Todo) error {
+		todo.complete()
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	listed = store.All(nil)
+	if len(listed) != 1 || listed[0].title != "new title in 05" || !listed[0].completed {
+		t.Errorf("after completing listed %+v, want the 05 todo completed", listed)
+	}
+}
+
+func TestUpdateSkippedDuplicate(t *testing.T) {
+	useTestDir(t)
+	config.duplicates = duplicatesSkip
+
+	now := time.Now()
+	writeTodoFile(t, "5", "\x00old title", now.Add(-time.Hour))
+	writeTodoFile(t, "05", "\x00new title in 05", now)
+
+	err := store.Update(5, func(todo *Todo) error {
+		todo.complete()
+		return nil
+	})
+	if !errors.Is(err, ErrTodoDuplicated) {
+		t.Errorf("Update error = %v, want ErrTodoDuplicated", err)
+	}
+}
+
+func TestDeleteRemovesDuplicates(t *testing.T) {
+	dir := useTestDir(t)
+
+	now := time.Now()
+	writeTodoFile(t, "5", "\x00old title", now.Add(-time.Hour))
+	writeTodoFile(t, "05", "\x00new title in 05", now)
+
+	todo, err := LoadTodo(5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	todo.delete()
+
+	if entries, _ := os.ReadDir(dir); len(entries) > 0 {
+		t.Errorf("%d files left after delete, an older duplicate would show up again", len(entries))
+	}
+}
+
+func TestCountMatchesAll(t *testing.T) {
+	useTestDir(t)
+
+	now := time.Now()
+	files := map[string]string{
+		"1": "\x02uuid=8f14e45f-ceea-4e6b-9b1a-3c2d5a6e7f80\ncompleted=true\n\nbuy milk\nand eggs",
+		"2": "\x02completed=false\nattachment=/home/me/list.txt\n\nwalk dog",
+		"3": "\x02completed=true\n", // header cut off before the title
+		"4": "completed: true\nread a book\n",
+		"5": "completed: false\nwater plants",
+		"6": "\x01call mom",
+		"7": "\x00fix bike",
+		"8": "cats", // binary, the first byte only looks like the text format
+		"9": "",
+	}
+	for name, data := range files {
+		writeTodoFile(t, name, data, now)
+	}
+
+	filters := map[string]func(*Todo) bool{
+		"nil":         nil,
+		"isPending":   isPending,
+		"isCompleted": isCompleted,
+	}
+	for name, filter := range filters {
+		if count, all := store.Count(filter), len(store.All(filter)); count != all {
+			t.Errorf("Count(%s) = %d, len(All(%s)) = %d", name, count, name, all)
+		}
+	}
+
+	if count := store.Count(nil); count != len(files) {
+		t.Errorf("Count(nil) = %d, want %d", count, len(files))
+	}
+}
+
+func TestExists(t *testing.T) {
+	dir := useTestDir(t)
+
+	now := time.Now()
+	writeTodoFile(t, "1", "\x00buy milk", now)
+	writeTodoFile(t, "005", "\x00walk dog", now)
+	if err := os.Mkdir(path.Join(dir, "7"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		id   TODOId
+		want bool
+	}{
+		{1, true},
+		{5, true}, // leading zeros
+		{7, true}, // a directory takes the id too
+		{2, false},
+		{50, false},
+	}
+
+	for _, test := range tests {
+		if got := store.Exists(test.id); got != test.want {
+			t.Errorf("Exists(%d) = %t, want %t", test.id, got, test.want)
+		}
+	}
+
+	todo, err := LoadTodo(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	todo.delete()
+	if store.Exists(1) {
+		t.Error("Exists(1) = true after delete")
+	}
+}
+
+func TestTodoIndexPath(t *testing.T) {
+	useTestDir(t)
+
+	now := time.Now()
+	writeTodoFile(t, "1", "\x00buy milk", now)
+	writeTodoFile(t, "005", "\x00walk dog", now)
+	writeTodoFile(t, "7", "\x00old seven", now.Add(-time.Hour))
+	writeTodoFile(t, "07", "\x00new seven", now)
+
+	index := indexTodoFiles()
+	if ids := index.ids(); !slices.Equal(ids, []TODOId{1, 5, 7}) {
+		t.Errorf("ids() = %v, want [1 5 7]", ids)
+	}
+
+	// the index has to agree with looking up each id on its own
+	for _, id := range []TODOId{1, 5, 7, 9} {
+		indexed, indexErr := index.path(id)
+		looked, err := store.path(id)
+		if indexed != looked || indexErr != err {
+			t.Errorf("index.path(%d) = %q, %v, store.path = %q, %v", id, indexed, indexErr, looked, err)
+		}
+	}
+	if filepath, _ := index.path(7); path.Base(filepath) != "07" {
+		t.Errorf("index.path(7) = %s, want the newest file 07", filepath)
+	}
+
+	config.duplicates = duplicatesSkip
+	if _, err := index.path(7); !errors.Is(err, ErrTodoDuplicated) {
+		t.Errorf("index.path(7) with duplicates = skip: %v, want ErrTodoDuplicated", err)
+	}
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+)
+
+// templates are plain files under templates/ in the todos directory, one todo title per line
+
+func getTemplatesDir() string {
+	return path.Join(getDirPath(), "templates")
+}
+
+func listTemplates() []string {
+	entries, err := os.ReadDir(getTemplatesDir())
+	if err != nil {
+		return nil
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") {
+			names = append(names, entry.Name())
+		}
+	}
+
+	return names
+}
+
+func readTemplate(name string) ([]string, error) {
+	data, err := os.ReadFile(path.Join(getTemplatesDir(), name))
+	if err != nil {
+		return nil, err
+	}
+
+	titles := make([]string, 0)
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.TrimSpace(line) != "" {
+			titles = append(titles, line)
+		}
+	}
+
+	return titles, nil
+}
+
+func getTemplateName() (string, bool) {
+	fmt.Print("template: ")
+	name := strings.TrimSpace(readLine())
+
+	if name == "" || strings.ContainsAny(name, `/\`) || strings.HasPrefix(name, ".") {
+		fmt.Println("Invalid template name")
+		return "", false
+	}
+
+	return name, true
+}
+
+func editTemplate() {
+	name, ok := getTemplateName()
+	if !ok {
+		return
+	}
+
+	if titles, err := readTemplate(name); err == nil {
+		fmt.Println("current todos:")
+		for _, title := range titles {
+			fmt.Println(title)
+		}
+	}
+
+	fmt.Println("Enter todo titles, one per line, an empty line to finish")
+
+	titles := make([]string, 0)
+	for {
+		fmt.Print("title: ")
+		title := getTodoTitle()
+		if title == "" {
+			break
+		}
+
+		if err := validateTitle(title); err != nil {
+			fmt.Printf("Invalid title: %v\n", err)
+			continue
+		}
+		titles = append(titles, title)
+	}
+
+	if len(titles) == 0 {
+		fmt.Println("Template not saved, it has no todos")
+		return
+	}
+
+	if err := os.MkdirAll(getTemplatesDir(), 0755); err != nil {
+		fmt.Printf("Error saving template: %+v\n", err)
+		return
+	}
+
+	data := strings.Join(titles, "\n") + "\n"
+	if err := os.WriteFile(path.Join(getTemplatesDir(), name), []byte(data), 0666); err != nil {
+		fmt.Printf("Error saving template: %+v\n", err)
+		return
+	}
+
+	fmt.Printf("Template %s saved with %d todos\n", name, len(titles))
+}
+
+func useTemplate() {
+	names := listTemplates()
+	if len(names) == 0 {
+		fmt.Println("No templates yet")
+		return
+	}
+
+	fmt.Printf("templates: %s\n", strings.Join(names, ", "))
+
+	name, ok := getTemplateName()
+	if !ok {
+		return
+	}
+
+	titles, err := readTemplate(name)
+	if err != nil {
+		fmt.Printf("Error reading template %s: %+v\n", name, err)
+		return
+	}
+
+	created := 0
+	for _, title := range titles {
+		if !checkTodoLimit() {
+			break
+		}
+
+		todo, err := addTodo(title)
+		if err != nil {
+			fmt.Printf("Error saving todo: %+v\n", err)
+			break
+		}
+		todo.print()
+		created++
+	}
+
+	fmt.Printf("Created %d todos from template %s\n", created, name)
+}
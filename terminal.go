@@ -0,0 +1,249 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Terminal describes what the output can display. Anything that can't be
+// detected falls back to plain ASCII, no color and 80 columns
+type Terminal struct {
+	tty   bool
+	utf8  bool
+	color bool
+	width int
+}
+
+var terminal = Terminal{width: 80}
+
+// stderrTerminal is for output that must stay out of piped stdout, like progress
+var stderrTerminal = Terminal{width: 80}
+
+func detectTerminal(out *os.File) Terminal {
+	term := Terminal{width: 80}
+
+	info, err := out.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return term
+	}
+	term.tty = true
+
+	// https://no-color.org
+	_, noColor := os.LookupEnv("NO_COLOR")
+	termName := os.Getenv("TERM")
+	term.color = !noColor && termName != "" && termName != "dumb"
+
+	term.utf8 = isUTF8Locale()
+
+	if columns, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && columns > 0 {
+		term.width = columns
+	}
+
+	return term
+}
+
+// isUTF8Locale checks the locale variables in the order the C library uses them
+func isUTF8Locale() bool {
+	for _, name := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		value := os.Getenv(name)
+		if value == "" {
+			continue
+		}
+
+		value = strings.ToLower(value)
+		return strings.Contains(value, "utf-8") || strings.Contains(value, "utf8")
+	}
+
+	return false
+}
+
+const (
+	ansiReset         = "\x1b[0m"
+	ansiStrikethrough = "\x1b[9m"
+	ansiReverse       = "\x1b[7m"
+)
+
+// themes are the accent colors the theme config can pick from
+var themes = map[string]string{
+	"default": "",
+	"red":     "\x1b[31m",
+	"green":   "\x1b[32m",
+	"yellow":  "\x1b[33m",
+	"blue":    "\x1b[34m",
+	"magenta": "\x1b[35m",
+	"cyan":    "\x1b[36m",
+}
+
+// style wraps s in the ANSI escape code, or gives s back as is when the terminal has no color
+func (term Terminal) style(code, s string) string {
+	if !term.color || code == "" {
+		return s
+	}
+
+	return code + s + ansiReset
+}
+
+// ellipsis is "…" where the locale can show it and "..." everywhere else
+func (term Terminal) ellipsis() string {
+	if term.utf8 {
+		return "…"
+	}
+	return "..."
+}
+
+const (
+	// only directories with at least this many todos get a progress line
+	progressMinTodos = 2000
+	progressStep     = 1000
+)
+
+// loadProgress prints "Loaded 5000/20000..." to stderr while a big directory is read
+type loadProgress struct {
+	total   int
+	enabled bool
+}
+
+func newLoadProgress(total int) loadProgress {
+	return loadProgress{
+		total:   total,
+		enabled: stderrTerminal.tty && total >= progressMinTodos,
+	}
+}
+
+func (progress loadProgress) update(loaded int) {
+	if !progress.enabled || loaded%progressStep != 0 {
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, "\rLoaded %d/%d%s", loaded, progress.total, stderrTerminal.ellipsis())
+}
+
+func (progress loadProgress) done() {
+	if !progress.enabled {
+		return
+	}
+
+	// overwrite the progress line so it doesn't stay above the output
+	fmt.Fprintf(os.Stderr, "\r%*s\r", len(fmt.Sprintf("Loaded %d/%d...", progress.total, progress.total)), "")
+}
+
+// accent colors headers, counts and prompts with the theme color
+func accent(s string) string {
+	return terminal.style(themes[config.theme], s)
+}
+
+// wideRanges are the East Asian wide and fullwidth blocks plus the emoji ones,
+// runes in them take two terminal columns
+var wideRanges = [][2]rune{
+	{0x1100, 0x115f},
+	{0x231a, 0x231b},
+	{0x23e9, 0x23ec},
+	{0x23f0, 0x23f0},
+	{0x23f3, 0x23f3},
+	{0x25fd, 0x25fe},
+	{0x2614, 0x2615},
+	{0x2648, 0x2653},
+	{0x267f, 0x267f},
+	{0x2693, 0x2693},
+	{0x26a1, 0x26a1},
+	{0x26aa, 0x26ab},
+	{0x26bd, 0x26be},
+	{0x26c4, 0x26c5},
+	{0x26ce, 0x26ce},
+	{0x26d4, 0x26d4},
+	{0x26ea, 0x26ea},
+	{0x26f2, 0x26f3},
+	{0x26f5, 0x26f5},
+	{0x26fa, 0x26fa},
+	{0x26fd, 0x26fd},
+	{0x2705, 0x2705},
+	{0x270a, 0x270b},
+	{0x2728, 0x2728},
+	{0x274c, 0x274c},
+	{0x274e, 0x274e},
+	{0x2753, 0x2755},
+	{0x2757, 0x2757},
+	{0x2795, 0x2797},
+	{0x27b0, 0x27b0},
+	{0x27bf, 0x27bf},
+	{0x2b1b, 0x2b1c},
+	{0x2b50, 0x2b50},
+	{0x2b55, 0x2b55},
+	{0x2e80, 0x303e},
+	{0x3041, 0x33ff},
+	{0x3400, 0x4dbf},
+	{0x4e00, 0x9fff},
+	{0xa000, 0xa4cf},
+	{0xa960, 0xa97f},
+	{0xac00, 0xd7a3},
+	{0xf900, 0xfaff},
+	{0xfe10, 0xfe19},
+	{0xfe30, 0xfe6f},
+	{0xff00, 0xff60},
+	{0xffe0, 0xffe6},
+	{0x1f004, 0x1f004},
+	{0x1f0cf, 0x1f0cf},
+	{0x1f18e, 0x1f18e},
+	{0x1f191, 0x1f19a},
+	{0x1f200, 0x1f251},
+	{0x1f300, 0x1f64f},
+	{0x1f680, 0x1f6ff},
+	{0x1f7e0, 0x1f7eb},
+	{0x1f900, 0x1f9ff},
+	{0x1fa70, 0x1faff},
+	{0x20000, 0x3fffd},
+}
+
+// runeWidth is how many columns the rune takes in a terminal: 0 for combining marks
+// and other invisible runes, 2 for wide ones, 1 for the rest
+func runeWidth(r rune) int {
+	if r == 0 || unicode.Is(unicode.Mn, r) || unicode.Is(unicode.Me, r) || unicode.Is(unicode.Cf, r) {
+		return 0
+	}
+
+	for _, wide := range wideRanges {
+		if r < wide[0] {
+			break
+		}
+		if r <= wide[1] {
+			return 2
+		}
+	}
+
+	return 1
+}
+
+// displayWidth is the number of terminal columns s takes, unlike len() or the rune count
+func displayWidth(s string) int {
+	width := 0
+	for _, r := range s {
+		width += runeWidth(r)
+	}
+	return width
+}
+
+// truncate cuts s to fit in the terminal width, wide runes count as two columns
+func (term Terminal) truncate(s string) string {
+	width := term.width
+	if displayWidth(s) <= width {
+		return s
+	}
+
+	// keeping columns for the ellipsis, "..." is three of them
+	ellipsis := term.ellipsis()
+	if width < displayWidth(ellipsis) {
+		return ""
+	}
+	used := 0
+	for i, r := range s {
+		if used+runeWidth(r) > width-displayWidth(ellipsis) {
+			return s[:i] + ellipsis
+		}
+		used += runeWidth(r)
+	}
+
+	return s
+}
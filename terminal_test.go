@@ -0,0 +1,172 @@
+package main
+
+import (
+	"os"
+	"testing"
+)
+
+// clearTerminalEnv unsets everything detectTerminal looks at for the test
+func clearTerminalEnv(t *testing.T) {
+	t.Helper()
+
+	for _, name := range []string{"NO_COLOR", "TERM", "LC_ALL", "LC_CTYPE", "LANG", "COLUMNS"} {
+		t.Setenv(name, "")
+		os.Unsetenv(name)
+	}
+}
+
+func TestDetectTerminalPipe(t *testing.T) {
+	clearTerminalEnv(t)
+	// everything that would turn features on if the output was a terminal
+	t.Setenv("TERM", "xterm-256color")
+	t.Setenv("LANG", "en_US.UTF-8")
+	t.Setenv("COLUMNS", "200")
+
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	defer writer.Close()
+
+	want := Terminal{width: 80}
+	if got := detectTerminal(writer); got != want {
+		t.Errorf("detectTerminal(pipe) = %+v, want %+v", got, want)
+	}
+}
+
+func TestDetectTerminalClosedFile(t *testing.T) {
+	clearTerminalEnv(t)
+
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	reader.Close()
+	writer.Close()
+
+	// Stat fails on a closed file, that has to fall back too
+	want := Terminal{width: 80}
+	if got := detectTerminal(writer); got != want {
+		t.Errorf("detectTerminal(closed file) = %+v, want %+v", got, want)
+	}
+}
+
+func TestIsUTF8Locale(t *testing.T) {
+	tests := []struct {
+		lcAll, lcCtype, lang string
+		want                 bool
+	}{
+		{"", "", "", false},
+		{"", "", "en_US.UTF-8", true},
+		{"", "", "de_DE.utf8", true},
+		{"", "", "C", false},
+		// LC_ALL wins over the others, even when it isn't UTF-8
+		{"C", "", "en_US.UTF-8", false},
+		{"", "en_US.UTF-8", "C", true},
+	}
+
+	for _, test := range tests {
+		clearTerminalEnv(t)
+		t.Setenv("LC_ALL", test.lcAll)
+		t.Setenv("LC_CTYPE", test.lcCtype)
+		t.Setenv("LANG", test.lang)
+
+		if got := isUTF8Locale(); got != test.want {
+			t.Errorf("isUTF8Locale() with LC_ALL=%q LC_CTYPE=%q LANG=%q = %t, want %t",
+				test.lcAll, test.lcCtype, test.lang, got, test.want)
+		}
+	}
+}
+
+func TestPlainTerminalOutput(t *testing.T) {
+	plain := Terminal{width: 80}
+
+	if got := plain.style(themes["cyan"], "todos"); got != "todos" {
+		t.Errorf("style without color = %q, want no escape codes", got)
+	}
+
+	config = loadConfig(t.TempDir())
+	if got := promptSegment(plain, 3); got != "-> 3" {
+		t.Errorf("promptSegment without UTF-8 = %q, want %q", got, "-> 3")
+	}
+	if got := promptSegment(Terminal{utf8: true}, 3); got != "⟶ 3" {
+		t.Errorf("promptSegment with UTF-8 = %q, want %q", got, "⟶ 3")
+	}
+}
+
+func TestDisplayWidth(t *testing.T) {
+	tests := []struct {
+		s    string
+		want int
+	}{
+		{"", 0},
+		{"buy milk", 8},
+		{"牛乳を買う", 10},
+		{"한국어", 6},
+		{"ｆｕｌｌ", 8},
+		{"🎉 party", 8},
+		{"e\u0301", 1},   // e and a combining acute accent
+		{"a\u200bb", 2},  // zero width space
+		{"日本 and 🚀", 11}, // mixed
+	}
+
+	for _, test := range tests {
+		if got := displayWidth(test.s); got != test.want {
+			t.Errorf("displayWidth(%q) = %d, want %d", test.s, got, test.want)
+		}
+	}
+}
+
+func TestRuneWidth(t *testing.T) {
+	tests := []struct {
+		r    rune
+		want int
+	}{
+		{'a', 1},
+		{'é', 1},
+		{'日', 2},
+		{'🎉', 2},
+		{'\u0301', 0},
+		{'\u200d', 0},
+	}
+
+	for _, test := range tests {
+		if got := runeWidth(test.r); got != test.want {
+			t.Errorf("runeWidth(%q) = %d, want %d", test.r, got, test.want)
+		}
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		utf8  bool
+		width int
+		s     string
+		want  string
+	}{
+		{true, 10, "buy milk", "buy milk"},
+		{true, 8, "buy milk", "buy milk"},
+		{true, 6, "buy milk", "buy m…"},
+		{false, 6, "buy milk", "buy..."},
+		// a wide rune that doesn't fit whole is left out instead of split
+		{true, 6, "牛乳を買う", "牛乳…"},
+		{false, 6, "牛乳を買う", "牛..."},
+		{true, 4, "🎉🎉🎉", "🎉…"},
+		{false, 4, "🎉🎉🎉", "..."},
+		{true, 1, "buy milk", "…"},
+		{false, 2, "buy milk", ""},
+		{true, 0, "buy milk", ""},
+	}
+
+	for _, test := range tests {
+		term := Terminal{utf8: test.utf8, width: test.width}
+		got := term.truncate(test.s)
+		if got != test.want {
+			t.Errorf("truncate(%q) at width %d, utf8 %t = %q, want %q", test.s, test.width, test.utf8, got, test.want)
+		}
+		if displayWidth(got) > test.width {
+			t.Errorf("truncate(%q) at width %d = %q, which is %d columns", test.s, test.width, got, displayWidth(got))
+		}
+	}
+}
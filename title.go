@@ -0,0 +1,30 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// validateTitle checks a new title against the title_* rules from the config,
+// the error names the rule that failed
+func validateTitle(title string) error {
+	if strings.TrimSpace(title) == "" {
+		return fmt.Errorf("title can't be empty")
+	}
+
+	if config.titleMaxLength > 0 && utf8.RuneCountInString(title) > config.titleMaxLength {
+		return fmt.Errorf("title is longer than %d characters (title_max_length)", config.titleMaxLength)
+	}
+
+	if i := strings.IndexAny(title, config.titleForbiddenChars); i >= 0 {
+		r, _ := utf8.DecodeRuneInString(title[i:])
+		return fmt.Errorf("title contains forbidden character %q (title_forbidden_chars)", r)
+	}
+
+	if config.titlePattern != nil && !config.titlePattern.MatchString(title) {
+		return fmt.Errorf("title doesn't match %s (title_pattern)", config.titlePattern)
+	}
+
+	return nil
+}
@@ -0,0 +1,20 @@
+package main
+
+import (
+	"crypto/rand"
+	"fmt"
+	"log"
+)
+
+// newUUID makes a random version 4 UUID. Numeric ids are only unique in one todos
+// directory, the uuid stays the same wherever the todo is copied to
+func newUUID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		log.Fatal(err)
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}